@@ -0,0 +1,113 @@
+// Offline IP to autonomous system (ASN) lookups.
+package asn
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ASN of a network operator.
+type ASN uint32
+
+// block of contiguous IPs announced by an ASN.
+type block struct {
+	start netip.Addr
+	end   netip.Addr
+	asn   ASN
+}
+
+// Database of IP blocks sorted by start address.
+type Database struct {
+	blocks []block
+}
+
+// Lookup the ASN announcing the ip, false if it isn't routed.
+func (d *Database) Lookup(ip netip.Addr) (ASN, bool) {
+	ip = ip.Unmap()
+
+	// first block starting after the ip, so the candidate is the one before it
+	i := sort.Search(len(d.blocks), func(i int) bool {
+		return ip.Less(d.blocks[i].start)
+	})
+	if i == 0 {
+		return 0, false
+	}
+
+	b := d.blocks[i-1]
+	if ip.Compare(b.end) > 0 || b.asn == 0 {
+		return 0, false
+	}
+
+	return b.asn, true
+}
+
+// Parse a database in the iptoasn.com TSV format.
+//
+// Each line is a range start, range end, ASN, country code, and description, separated by tabs. IPv4, IPv6, and
+// combined files are all supported. ASN 0 marks ranges which are not routed.
+func Parse(r io.Reader) (*Database, error) {
+	d := &Database{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 fields", line)
+		}
+
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		n, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		d.blocks = append(d.blocks, block{start: start.Unmap(), end: end.Unmap(), asn: ASN(n)})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(d.blocks, func(i, j int) bool {
+		return d.blocks[i].start.Less(d.blocks[j].start)
+	})
+
+	return d, nil
+}
+
+// Load a database file in the iptoasn.com TSV format, gzipped if the name ends in .gz.
+func Load(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	return Parse(r)
+}
@@ -0,0 +1,83 @@
+package asn
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const database = "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+	"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+	"2001:db8::\t2001:db8::ffff\t64496\tZZ\tDOCUMENTATION\n" +
+	"1.0.4.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP\n"
+
+func TestDatabase_Lookup(t *testing.T) {
+	d, err := Parse(strings.NewReader(database))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		ip     string
+		want   ASN
+		wantOk bool
+	}{
+		{
+			name:   "start of block",
+			ip:     "1.0.0.0",
+			want:   13335,
+			wantOk: true,
+		},
+		{
+			name:   "inside block",
+			ip:     "1.0.5.5",
+			want:   38803,
+			wantOk: true,
+		},
+		{
+			name:   "not routed",
+			ip:     "1.0.2.1",
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "before all blocks",
+			ip:     "0.0.0.1",
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "after all ipv4 blocks",
+			ip:     "1.0.8.1",
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "ipv6",
+			ip:     "2001:db8::1",
+			want:   64496,
+			wantOk: true,
+		},
+		{
+			name:   "ipv4 mapped",
+			ip:     "::ffff:1.0.0.1",
+			want:   13335,
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.Lookup(netip.MustParseAddr(tt.ip))
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Database.Lookup() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("1.0.0.0\tnope\t1\n")); err == nil {
+		t.Errorf("Parse() expected error")
+	}
+}
@@ -0,0 +1,84 @@
+package raiju
+
+import (
+	"math"
+	"sync"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// feeBudget of sats shared by concurrent rebalances, nil is no limit.
+type feeBudget struct {
+	mu        sync.Mutex
+	remaining lightning.Satoshi
+}
+
+// newFeeBudget of the smaller of the run and daily budgets, nil if neither is set.
+func newFeeBudget(run lightning.Satoshi, daily lightning.Satoshi, spentToday lightning.Satoshi) *feeBudget {
+	if run == 0 && daily == 0 {
+		return nil
+	}
+
+	remaining := run
+	if daily > 0 {
+		left := daily - spentToday
+		if left < 0 {
+			left = 0
+		}
+		if run == 0 || left < remaining {
+			remaining = left
+		}
+	}
+
+	return &feeBudget{remaining: remaining}
+}
+
+// maxFeeOf a payment of amount at the fee rate, rounded up to the sat so fractional rates are never under reserved.
+func maxFeeOf(amount lightning.Satoshi, fee lightning.FeePPM) lightning.Satoshi {
+	return lightning.Satoshi(math.Ceil(float64(amount) * float64(fee) / 1_000_000))
+}
+
+// reserve the max fee of paying amount, shrinking the amount to what the budget can afford.
+//
+// Returns the affordable amount and the fee reserved, which must be settled once the payment is done.
+func (b *feeBudget) reserve(amount lightning.Satoshi, fee lightning.FeePPM) (lightning.Satoshi, lightning.Satoshi) {
+	// only a zero rate is free, rates under a ppm still reserve at least a sat
+	if b == nil || fee <= 0 {
+		return amount, 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if affordable := math.Floor(float64(b.remaining) * 1_000_000 / float64(fee)); float64(amount) > affordable {
+		amount = lightning.Satoshi(affordable)
+	}
+	reserved := maxFeeOf(amount, fee)
+	b.remaining -= reserved
+
+	return amount, reserved
+}
+
+// settle a reservation with the fee actually paid, returning the rest to the budget.
+func (b *feeBudget) settle(reserved lightning.Satoshi, paid lightning.Satoshi) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remaining += reserved - paid
+}
+
+// spent is true once the budget can't cover any more fees.
+func (b *feeBudget) spent() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.remaining <= 0
+}
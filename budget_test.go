@@ -0,0 +1,103 @@
+package raiju
+
+import (
+	"testing"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func Test_newFeeBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        lightning.Satoshi
+		daily      lightning.Satoshi
+		spentToday lightning.Satoshi
+		want       *feeBudget
+	}{
+		{
+			name: "no limit",
+			want: nil,
+		},
+		{
+			name: "run only",
+			run:  10,
+			want: &feeBudget{remaining: 10},
+		},
+		{
+			name:       "daily left is smaller",
+			run:        10,
+			daily:      20,
+			spentToday: 15,
+			want:       &feeBudget{remaining: 5},
+		},
+		{
+			name:       "daily already spent",
+			daily:      20,
+			spentToday: 25,
+			want:       &feeBudget{remaining: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newFeeBudget(tt.run, tt.daily, tt.spentToday)
+			if (got == nil) != (tt.want == nil) || (got != nil && got.remaining != tt.want.remaining) {
+				t.Errorf("newFeeBudget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_feeBudget_reserve(t *testing.T) {
+	b := newFeeBudget(3, 0, 0)
+
+	// a 1000 ppm fee on 2500 sats rounds up to 3 sats
+	amount, reserved := b.reserve(2500, 1000)
+	if amount != 2500 || reserved != 3 || !b.spent() {
+		t.Errorf("feeBudget.reserve() = %v, %v, want 2500, 3 and spent", amount, reserved)
+	}
+
+	b.settle(reserved, 1)
+
+	// only 2 sats of fees left to cover
+	amount, reserved = b.reserve(5000, 1000)
+	if amount != 2000 || reserved != 2 {
+		t.Errorf("feeBudget.reserve() = %v, %v, want 2000, 2", amount, reserved)
+	}
+}
+
+func Test_feeBudget_reserve_fractional(t *testing.T) {
+	tests := []struct {
+		name         string
+		remaining    lightning.Satoshi
+		amount       lightning.Satoshi
+		fee          lightning.FeePPM
+		wantAmount   lightning.Satoshi
+		wantReserved lightning.Satoshi
+	}{
+		{
+			name:         "under a ppm",
+			remaining:    1,
+			amount:       5_000_000,
+			fee:          0.5,
+			wantAmount:   2_000_000,
+			wantReserved: 1,
+		},
+		{
+			name:         "fraction isn't truncated",
+			remaining:    10,
+			amount:       1_000_000,
+			fee:          1.9,
+			wantAmount:   1_000_000,
+			wantReserved: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newFeeBudget(tt.remaining, 0, 0)
+			amount, reserved := b.reserve(tt.amount, tt.fee)
+			if amount != tt.wantAmount || reserved != tt.wantReserved {
+				t.Errorf("feeBudget.reserve() = %v, %v, want %v, %v", amount, reserved, tt.wantAmount, tt.wantReserved)
+			}
+		})
+	}
+}
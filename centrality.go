@@ -0,0 +1,146 @@
+package raiju
+
+import (
+	"math/rand"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// graph is an integer indexed adjacency list of the network, the hot loops of centrality are too slow with maps.
+type graph struct {
+	index map[lightning.PubKey]int
+	adj   [][]int
+}
+
+// newGraph indexes the neighbors of nodes.
+func newGraph(nodes map[lightning.PubKey]*RelativeNode) graph {
+	g := graph{
+		index: make(map[lightning.PubKey]int, len(nodes)),
+		adj:   make([][]int, 0, len(nodes)),
+	}
+
+	for k := range nodes {
+		g.index[k] = len(g.adj)
+		g.adj = append(g.adj, nil)
+	}
+
+	for k, n := range nodes {
+		i := g.index[k]
+		for _, neighbor := range n.Neighbors {
+			g.adj[i] = append(g.adj[i], g.index[neighbor])
+		}
+	}
+
+	return g
+}
+
+// connect nodes a and b with a channel.
+func (g graph) connect(a, b int) {
+	g.adj[a] = append(g.adj[a], b)
+	g.adj[b] = append(g.adj[b], a)
+}
+
+// disconnect the most recent channel added with connect.
+func (g graph) disconnect(a, b int) {
+	g.adj[a] = g.adj[a][:len(g.adj[a])-1]
+	g.adj[b] = g.adj[b][:len(g.adj[b])-1]
+}
+
+// sample random source nodes, all nodes are used if samples exceeds the size of the graph.
+func (g graph) sample(samples int) []int {
+	sources := rand.Perm(len(g.adj))
+	if samples < len(sources) {
+		sources = sources[:samples]
+	}
+
+	return sources
+}
+
+// betweenness of the target node approximated by only accumulating shortest paths from the sources.
+//
+// This is Brandes' algorithm with the result scaled up by the sampling ratio, so it estimates the number of
+// (ordered) pairs of nodes whose shortest paths run through the target.
+func (g graph) betweenness(target int, sources []int) float64 {
+	if len(sources) == 0 {
+		return 0
+	}
+
+	n := len(g.adj)
+	distance := make([]int, n)
+	paths := make([]float64, n)
+	dependency := make([]float64, n)
+	predecessors := make([][]int, n)
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+
+	var total float64
+	for _, s := range sources {
+		if s == target {
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			distance[i] = -1
+			paths[i] = 0
+			dependency[i] = 0
+			predecessors[i] = predecessors[i][:0]
+		}
+		stack = stack[:0]
+		queue = append(queue[:0], s)
+		distance[s] = 0
+		paths[s] = 1
+
+		// BFS counting the number of shortest paths to each node
+		for head := 0; head < len(queue); head++ {
+			v := queue[head]
+			stack = append(stack, v)
+			for _, w := range g.adj[v] {
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					paths[w] += paths[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		// walk back from the furthest nodes accumulating dependencies
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				dependency[v] += paths[v] / paths[w] * (1 + dependency[w])
+			}
+		}
+
+		total += dependency[target]
+	}
+
+	return total * float64(n) / float64(len(sources))
+}
+
+// centralityGains of each candidate's closest root node if a channel was opened between them.
+func centralityGains(nodes map[lightning.PubKey]*RelativeNode, candidates []RelativeNode, samples int) {
+	g := newGraph(nodes)
+	sources := g.sample(samples)
+
+	// every root's own centrality is only worked out once
+	bases := make(map[int]float64)
+	for i := range candidates {
+		r, ok := g.index[candidates[i].Root]
+		if !ok {
+			continue
+		}
+		base, ok := bases[r]
+		if !ok {
+			base = g.betweenness(r, sources)
+			bases[r] = base
+		}
+
+		c := g.index[candidates[i].PubKey]
+		g.connect(r, c)
+		candidates[i].Centrality = g.betweenness(r, sources) - base
+		g.disconnect(r, c)
+	}
+}
@@ -0,0 +1,105 @@
+package raiju
+
+import (
+	"testing"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func Test_graph_betweenness(t *testing.T) {
+	// a linear network (0) <=> (1) <=> (2)
+	line := graph{
+		adj: [][]int{{1}, {0, 2}, {1}},
+	}
+	// a square network (0) <=> (1) <=> (2) <=> (3) <=> (0)
+	square := graph{
+		adj: [][]int{{1, 3}, {0, 2}, {1, 3}, {2, 0}},
+	}
+
+	type args struct {
+		target  int
+		sources []int
+	}
+	tests := []struct {
+		name string
+		g    graph
+		args args
+		want float64
+	}{
+		{
+			name: "middle of a line routes both directions",
+			g:    line,
+			args: args{
+				target:  1,
+				sources: []int{0, 1, 2},
+			},
+			want: 2,
+		},
+		{
+			name: "end of a line routes nothing",
+			g:    line,
+			args: args{
+				target:  0,
+				sources: []int{0, 1, 2},
+			},
+			want: 0,
+		},
+		{
+			name: "shortest paths are split evenly",
+			g:    square,
+			args: args{
+				target:  0,
+				sources: []int{0, 1, 2, 3},
+			},
+			want: 1,
+		},
+		{
+			name: "samples are scaled up to the whole graph",
+			g:    line,
+			args: args{
+				target:  1,
+				sources: []int{0},
+			},
+			want: 3,
+		},
+		{
+			name: "no samples",
+			g:    line,
+			args: args{
+				target:  1,
+				sources: []int{},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.g.betweenness(tt.args.target, tt.args.sources); got != tt.want {
+				t.Errorf("graph.betweenness() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_centralityGains(t *testing.T) {
+	// two pairs (A) <=> (B) and (C) <=> (D), rooted at A and D
+	nodes := map[lightning.PubKey]*RelativeNode{
+		pubKeyA: {Node: lightning.Node{PubKey: pubKeyA}, Neighbors: []lightning.PubKey{pubKeyB}},
+		pubKeyB: {Node: lightning.Node{PubKey: pubKeyB}, Neighbors: []lightning.PubKey{pubKeyA}},
+		pubKeyC: {Node: lightning.Node{PubKey: pubKeyC}, Neighbors: []lightning.PubKey{pubKeyD}},
+		pubKeyD: {Node: lightning.Node{PubKey: pubKeyD}, Neighbors: []lightning.PubKey{pubKeyC}},
+	}
+	candidates := []RelativeNode{
+		{Node: lightning.Node{PubKey: pubKeyC}, Root: pubKeyA},
+		{Node: lightning.Node{PubKey: pubKeyB}, Root: pubKeyD},
+	}
+
+	centralityGains(nodes, candidates, 4)
+
+	// each root ends up in the middle of the joined line, routing both ways between the two nodes on either side
+	for _, c := range candidates {
+		if c.Centrality != 4 {
+			t.Errorf("centralityGains() %s from root %s = %v, want 4", c.PubKey, c.Root, c.Centrality)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package raiju
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// forwardWindow of recent forwards counted per channel.
+const forwardWindow = time.Hour * 24 * 7
+
+// ChannelStatus of a local channel's liquidity and activity.
+type ChannelStatus struct {
+	lightning.Channel
+	// TargetFee the liquidity fees call for at the channel's current liquidity
+	TargetFee lightning.FeePPM
+	// Forwards through the channel within the forward window
+	Forwards int64
+}
+
+// ChannelStatuses of the local node, sorted by channel ID.
+func (r Raiju) ChannelStatuses(ctx context.Context) ([]ChannelStatus, error) {
+	statuses, _, err := r.channelStatuses(ctx)
+	return statuses, err
+}
+
+// channelStatuses of the local node along with the forwards they were counted from.
+func (r Raiju) channelStatuses(ctx context.Context) ([]ChannelStatus, forwardTimes, error) {
+	channels, err := r.l.ListChannels(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list channels: %w", err)
+	}
+
+	forwards, err := r.l.ForwardingHistory(ctx, time.Now().Add(-forwardWindow))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to pull forwarding history: %w", err)
+	}
+
+	times := make(forwardTimes)
+	for _, f := range forwards {
+		times[f.ChannelIn] = append(times[f.ChannelIn], f.Timestamp)
+		times[f.ChannelOut] = append(times[f.ChannelOut], f.Timestamp)
+	}
+
+	statuses := make([]ChannelStatus, len(channels))
+	for i, c := range channels {
+		statuses[i] = ChannelStatus{
+			Channel:   c,
+			TargetFee: r.f.Fee(c),
+			Forwards:  int64(len(times[c.ChannelID])),
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ChannelID < statuses[j].ChannelID
+	})
+
+	return statuses, times, nil
+}
+
+// forwardTimes of forwards by channel.
+type forwardTimes map[lightning.ChannelID][]time.Time
+
+// prune forwards before the given time, dropping channels with none left.
+func (f forwardTimes) prune(before time.Time) {
+	for id, times := range f {
+		kept := times[:0]
+		for _, t := range times {
+			if !t.Before(before) {
+				kept = append(kept, t)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(f, id)
+		} else {
+			f[id] = kept
+		}
+	}
+}
+
+// WatchChannels sends the status of all local channels and then again every time a forward shifts liquidity.
+func (r Raiju) WatchChannels(ctx context.Context) (<-chan []ChannelStatus, <-chan error, error) {
+	statuses, times, err := r.channelStatuses(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cc, ce, err := r.l.SubscribeChannelUpdates(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to subscribe to channel updates: %w", err)
+	}
+
+	// buffer the channel for the first snapshot
+	updates := make(chan []ChannelStatus, 1)
+	errors := make(chan error)
+
+	updates <- statuses
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case channels := <-cc:
+				statuses = updateStatuses(statuses, channels, r.f, times, time.Now())
+				select {
+				case updates <- statuses:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-ce:
+				select {
+				case errors <- fmt.Errorf("error listening to channel updates: %w", err):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, errors, nil
+}
+
+// updateStatuses with the channels involved in a forward at now, returning a new snapshot.
+//
+// Channels opened since the last snapshot are added and forwards which fell out of the window are no longer counted.
+func updateStatuses(statuses []ChannelStatus, channels lightning.Channels, f LiquidityFees, times forwardTimes, now time.Time) []ChannelStatus {
+	updated := make([]ChannelStatus, len(statuses))
+	copy(updated, statuses)
+
+	for _, c := range channels {
+		times[c.ChannelID] = append(times[c.ChannelID], now)
+
+		i := slices.IndexFunc(updated, func(s ChannelStatus) bool { return s.ChannelID == c.ChannelID })
+		if i < 0 {
+			updated = append(updated, ChannelStatus{})
+			i = len(updated) - 1
+		}
+		updated[i].Channel = c
+		updated[i].TargetFee = f.Fee(c)
+	}
+
+	times.prune(now.Add(-forwardWindow))
+	for i := range updated {
+		updated[i].Forwards = int64(len(times[updated[i].ChannelID]))
+	}
+	sort.Slice(updated, func(i, j int) bool {
+		return updated[i].ChannelID < updated[j].ChannelID
+	})
+
+	return updated
+}
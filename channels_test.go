@@ -0,0 +1,139 @@
+package raiju
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_WatchChannels(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	high := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 10, Node1: pubKeyA, Node2: pubKeyB},
+		ChannelID:     1,
+		LocalBalance:  9,
+		LocalFee:      5,
+		RemoteBalance: 1,
+	}
+	low := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 10, Node1: pubKeyA, Node2: pubKeyC},
+		ChannelID:     2,
+		LocalBalance:  1,
+		LocalFee:      500,
+		RemoteBalance: 9,
+	}
+	// a forward drained the high channel
+	drained := high
+	drained.LocalBalance = 5
+	drained.RemoteBalance = 5
+
+	cc := make(chan lightning.Channels)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := Raiju{
+		l: &lightningerMock{
+			ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
+				return lightning.Channels{low, high}, nil
+			},
+			ForwardingHistoryFunc: func(ctx context.Context, since time.Time) ([]lightning.Forward, error) {
+				now := time.Now()
+				return []lightning.Forward{
+					{Timestamp: now, ChannelIn: 1, ChannelOut: 2},
+					{Timestamp: now, ChannelIn: 2, ChannelOut: 3},
+				}, nil
+			},
+			SubscribeChannelUpdatesFunc: func(ctx context.Context) (<-chan lightning.Channels, <-chan error, error) {
+				return cc, make(chan error), nil
+			},
+		},
+		f: f,
+	}
+
+	updates, _, err := r.WatchChannels(ctx)
+	if err != nil {
+		t.Fatalf("Raiju.WatchChannels() error = %v", err)
+	}
+
+	want := []ChannelStatus{
+		{Channel: high, TargetFee: 5, Forwards: 1},
+		{Channel: low, TargetFee: 500, Forwards: 2},
+	}
+	if got := <-updates; !reflect.DeepEqual(got, want) {
+		t.Errorf("Raiju.WatchChannels() first = %v, want %v", got, want)
+	}
+
+	cc <- lightning.Channels{drained}
+
+	want = []ChannelStatus{
+		{Channel: drained, TargetFee: 50, Forwards: 2},
+		{Channel: low, TargetFee: 500, Forwards: 2},
+	}
+	if got := <-updates; !reflect.DeepEqual(got, want) {
+		t.Errorf("Raiju.WatchChannels() update = %v, want %v", got, want)
+	}
+}
+
+func Test_updateStatuses(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+	now := time.Now()
+
+	balanced := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 10, Node1: pubKeyA, Node2: pubKeyB},
+		ChannelID:     2,
+		LocalBalance:  5,
+		RemoteBalance: 5,
+	}
+	opened := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 10, Node1: pubKeyA, Node2: pubKeyC},
+		ChannelID:     1,
+		LocalBalance:  9,
+		RemoteBalance: 1,
+	}
+
+	type args struct {
+		statuses []ChannelStatus
+		channels lightning.Channels
+		times    forwardTimes
+	}
+	tests := []struct {
+		name string
+		args args
+		want []ChannelStatus
+	}{
+		{
+			name: "forwards outside the window are no longer counted",
+			args: args{
+				statuses: []ChannelStatus{{Channel: balanced, TargetFee: 50, Forwards: 2}},
+				channels: lightning.Channels{balanced},
+				times: forwardTimes{
+					2: {now.Add(-forwardWindow - time.Hour), now.Add(-time.Hour)},
+				},
+			},
+			want: []ChannelStatus{{Channel: balanced, TargetFee: 50, Forwards: 2}},
+		},
+		{
+			name: "channels opened since the snapshot are added",
+			args: args{
+				statuses: []ChannelStatus{{Channel: balanced, TargetFee: 50}},
+				channels: lightning.Channels{opened},
+				times:    forwardTimes{},
+			},
+			want: []ChannelStatus{
+				{Channel: opened, TargetFee: 5, Forwards: 1},
+				{Channel: balanced, TargetFee: 50},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateStatuses(tt.args.statuses, tt.args.channels, f, tt.args.times, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("updateStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -18,13 +22,25 @@ import (
 	"github.com/rivo/tview"
 
 	"github.com/nyonson/raiju"
+	"github.com/nyonson/raiju/asn"
 	"github.com/nyonson/raiju/lightning"
+	"github.com/nyonson/raiju/store"
 	"github.com/nyonson/raiju/view"
 )
 
 const (
 	// Bump up from the default of 30s to 5m since a lot of raiju's commands are long pulls of data
 	rpcTimeout = time.Minute * 5
+	// uptimeFile in the data directory holds the probe history
+	uptimeFile = "uptime.json"
+	// uptimeWindow of probes used to calculate uptime, older probes are pruned
+	uptimeWindow = time.Hour * 24 * 30
+	// rebalanceFile in the data directory holds the rebalance history
+	rebalanceFile = "rebalances.json"
+	// rebalanceWindow of attempts kept in the rebalance history
+	rebalanceWindow = time.Hour * 24 * 30
+	// assumedFile in the data directory holds the nodes assumed in the dashboard
+	assumedFile = "assumed.txt"
 )
 
 func parseFees(thresholds string, fees string, stickiness float64) (raiju.LiquidityFees, error) {
@@ -57,17 +73,304 @@ func parseFees(thresholds string, fees string, stickiness float64) (raiju.Liquid
 	return lf, nil
 }
 
+func parseScorer(scorer string) (raiju.Scorer, error) {
+	// using FieldsFunc to handle empty string case correctly
+	rawScorers := strings.FieldsFunc(scorer, func(c rune) bool { return c == ',' })
+	names := make([]string, len(rawScorers))
+	weights := make([]float64, len(rawScorers))
+	for i, s := range rawScorers {
+		// weights are optional and default to 1
+		name, weight, found := strings.Cut(s, ":")
+		names[i] = name
+		weights[i] = 1
+		if found {
+			w, err := strconv.ParseFloat(weight, 64)
+			if err != nil {
+				return nil, err
+			}
+			weights[i] = w
+		}
+	}
+
+	return raiju.NewScorer(names, weights)
+}
+
+func parseChannels(args []string) ([]lightning.NewChannel, error) {
+	channels := make([]lightning.NewChannel, len(args))
+	for i, a := range args {
+		pubKey, amount, found := strings.Cut(a, ":")
+		if !found {
+			return nil, fmt.Errorf("channel must be in the form <pubkey>:<amount>: %s", a)
+		}
+
+		sats, err := strconv.ParseInt(amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse amount: %s", amount)
+		}
+
+		channels[i] = lightning.NewChannel{
+			PubKey: lightning.PubKey(pubKey),
+			Amount: lightning.Satoshi(sats),
+		}
+	}
+
+	return channels, nil
+}
+
+// readPlan of channels from a file in the lncli batchopenchannel JSON format output by the plan command.
+func readPlan(path string) ([]lightning.NewChannel, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []struct {
+		NodePubkey         lightning.PubKey  `json:"node_pubkey"`
+		LocalFundingAmount lightning.Satoshi `json:"local_funding_amount"`
+	}
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, fmt.Errorf("unable to parse plan: %w", err)
+	}
+
+	channels := make([]lightning.NewChannel, len(plan))
+	for i, c := range plan {
+		channels[i] = lightning.NewChannel{
+			PubKey: c.NodePubkey,
+			Amount: c.LocalFundingAmount,
+		}
+	}
+
+	return channels, nil
+}
+
+// confirm with the user on stdin, anything other than yes is a no.
+func confirm(prompt string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// newPrinter of command results to stdout.
+func newPrinter(format string) (view.Printer, error) {
+	f, err := view.ParseFormat(format)
+	if err != nil {
+		return view.Printer{}, err
+	}
+
+	return view.NewPrinter(os.Stdout, f), nil
+}
+
+// errRoutesMacaroon when probing or building routes is asked for without the macaroon it needs.
+var errRoutesMacaroon = errors.New("probing and building routes requires the mac-path flag")
+
+// newLndClient which can also probe and build routes if asked to, authenticated with the macaroon at macPath.
+func newLndClient(services *lndclient.GrpcLndServices, network string, macPath string, routes bool) (lightning.LndClient, error) {
+	if !routes {
+		return lightning.NewLndClient(services, network), nil
+	}
+	if macPath == "" {
+		return lightning.LndClient{}, errRoutesMacaroon
+	}
+
+	return lightning.NewLndClientWithMacaroon(services, network, macPath)
+}
+
+// loadRebalances history from the data directory.
+func loadRebalances(dataDir string) (raiju.RebalanceHistory, error) {
+	var history raiju.RebalanceHistory
+	if err := store.Load(filepath.Join(dataDir, rebalanceFile), &history); err != nil {
+		return raiju.RebalanceHistory{}, fmt.Errorf("unable to load rebalance history: %w", err)
+	}
+
+	return history, nil
+}
+
+// recordRebalances attempted to the history in the data directory, safe to call from concurrent processes.
+func recordRebalances(dataDir string, attempts []raiju.RebalanceAttempt) error {
+	var history raiju.RebalanceHistory
+	err := store.Update(filepath.Join(dataDir, rebalanceFile), &history, func() error {
+		history.Record(attempts)
+		history.Prune(time.Now().Add(-rebalanceWindow))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to record rebalance history: %w", err)
+	}
+
+	return nil
+}
+
+// candidatesFlags are shared by the commands which search for candidates.
+type candidatesFlags struct {
+	minCapacity         *int64
+	minChannels         *int64
+	minDistance         *int64
+	minDistantNeighbors *int64
+	pubkey              *string
+	assume              *string
+	clearnet            *bool
+	centralitySamples   *int
+	include             *string
+	exclude             *string
+	excludeClosed       *bool
+	minUptime           *float64
+	hybrid              *bool
+	networks            *string
+	asnDatabase         *string
+	uniqueASN           *bool
+	uniqueSubnet        *bool
+	maxOutboundFee      *float64
+	maxInboundFee       *float64
+	minChannelSize      *int64
+	minEnabledPercent   *float64
+	minChannelAge       *int64
+}
+
+func newCandidatesFlags(fs *flag.FlagSet) candidatesFlags {
+	return candidatesFlags{
+		minCapacity:         fs.Int64("min-capacity", 1000000, "Minimum capacity of a node in satoshis"),
+		minChannels:         fs.Int64("min-channels", 1, "Candidate must have at least this many channels"),
+		minDistance:         fs.Int64("min-distance", 2, "Candidate must be at least this far away (0 is root node and 1 is direct connection)"),
+		minDistantNeighbors: fs.Int64("min-distant-neighbors", 0, "Candidate must have a minimum number of distant neighbors"),
+		pubkey:              fs.String("pubkey", "", "Comma separated nodes to span out from, distance is from the closest, defaults to the connected node"),
+		assume:              fs.String("assume", "", "Comma separated pubkeys to assume channels too from the first root node"),
+		clearnet:            fs.Bool("clearnet", true, "Filter nodes without a public clearnet address"),
+		centralitySamples:   fs.Int("centrality-samples", 0, "Calculate the betweenness centrality a channel would add to the closest root node, approximated from this many sampled nodes (0 disables), only ranks with the centrality scorer"),
+		include:             fs.String("include", "", "File listing the only nodes to consider, one pubkey or alias:<regex> per line"),
+		exclude:             fs.String("exclude", "", "File listing nodes to skip, one pubkey or alias:<regex> per line"),
+		excludeClosed:       fs.Bool("exclude-closed", false, "Skip nodes which had a channel with the local node in the past"),
+		hybrid:              fs.Bool("hybrid", false, "Filter nodes which are not reachable over both tor and clearnet"),
+		networks:            fs.String("networks", "", "Comma separated networks (ipv4, ipv6, torv3, i2p, dns) a candidate must have an address on"),
+		asnDatabase:         fs.String("asn-db", "", "IP to ASN database file in the iptoasn.com TSV format, optionally gzipped"),
+		uniqueASN:           fs.Bool("unique-asn", false, "Filter nodes hosted in the same ASN as a current peer, requires asn-db"),
+		uniqueSubnet:        fs.Bool("unique-subnet", false, "Filter nodes hosted in the same subnet (/24 IPv4 or /48 IPv6) as a current peer"),
+		maxOutboundFee:      fs.Float64("max-outbound-fee", 0, "Maximum median outbound fee PPM of a candidate, 0 is no limit"),
+		maxInboundFee:       fs.Float64("max-inbound-fee", 0, "Maximum median fee PPM a candidate's peers charge to route to it, 0 is no limit"),
+		minChannelSize:      fs.Int64("min-median-channel-size", 0, "Minimum median channel size of a candidate in satoshis"),
+		minEnabledPercent:   fs.Float64("min-enabled-percent", 0, "Minimum percent of a candidate's channels which are enabled"),
+		minChannelAge:       fs.Int64("min-channel-age", 0, "Candidate must have a channel at least this many blocks old"),
+		minUptime:           fs.Float64("min-uptime", 0, "Minimum percent of daemon probes a node must have been reachable for, nodes never probed are kept"),
+	}
+}
+
+// request built from the flags and the global settings.
+func (cf candidatesFlags) request(scorer string, dataDir string) (raiju.CandidatesRequest, error) {
+	if *cf.minDistance < 2 {
+		return raiju.CandidatesRequest{}, errors.New("min-distance must be greater than 1")
+	}
+
+	s, err := parseScorer(scorer)
+	if err != nil {
+		return raiju.CandidatesRequest{}, err
+	}
+
+	if strings.Contains(scorer, "centrality") && *cf.centralitySamples == 0 {
+		return raiju.CandidatesRequest{}, errors.New("centrality scorer requires centrality-samples")
+	}
+
+	// using FieldsFunc to handle empty string case correctly
+	p := strings.FieldsFunc(*cf.pubkey, func(c rune) bool { return c == ',' })
+	pubKeys := make([]lightning.PubKey, len(p))
+	for i, k := range p {
+		pubKeys[i] = lightning.PubKey(k)
+	}
+
+	a := strings.FieldsFunc(*cf.assume, func(c rune) bool { return c == ',' })
+	assume := make([]lightning.PubKey, len(a))
+	for i, p := range a {
+		assume[i] = lightning.PubKey(p)
+	}
+
+	// using FieldsFunc to handle empty string case correctly
+	n := strings.FieldsFunc(*cf.networks, func(c rune) bool { return c == ',' })
+	networks := make([]lightning.AddressType, len(n))
+	for i, name := range n {
+		networks[i], err = lightning.ParseAddressType(name)
+		if err != nil {
+			return raiju.CandidatesRequest{}, err
+		}
+	}
+
+	if *cf.uniqueASN && *cf.asnDatabase == "" {
+		return raiju.CandidatesRequest{}, errors.New("unique-asn requires asn-db")
+	}
+
+	var asns *asn.Database
+	if *cf.asnDatabase != "" {
+		asns, err = asn.Load(*cf.asnDatabase)
+		if err != nil {
+			return raiju.CandidatesRequest{}, fmt.Errorf("unable to load asn database: %w", err)
+		}
+	}
+
+	var include, exclude raiju.NodeList
+	if *cf.include != "" {
+		include, err = raiju.ReadNodeList(*cf.include)
+		if err != nil {
+			return raiju.CandidatesRequest{}, fmt.Errorf("unable to read include list: %w", err)
+		}
+	}
+	if *cf.exclude != "" {
+		exclude, err = raiju.ReadNodeList(*cf.exclude)
+		if err != nil {
+			return raiju.CandidatesRequest{}, fmt.Errorf("unable to read exclude list: %w", err)
+		}
+	}
+
+	// loaded even without a minimum since the reliability scorer ranks by it
+	history := make(raiju.UptimeHistory)
+	if err := store.Load(filepath.Join(dataDir, uptimeFile), &history); err != nil {
+		return raiju.CandidatesRequest{}, fmt.Errorf("unable to load uptime history: %w", err)
+	}
+	uptime := history.Uptime(time.Now().Add(-uptimeWindow))
+
+	return raiju.CandidatesRequest{
+		PubKeys:              pubKeys,
+		MinCapacity:          lightning.Satoshi(*cf.minCapacity),
+		MinChannels:          *cf.minChannels,
+		MinDistance:          *cf.minDistance,
+		MinDistantNeighbors:  *cf.minDistantNeighbors,
+		MinUpdated:           time.Now().Add(-2 * 24 * time.Hour),
+		Assume:               assume,
+		Clearnet:             *cf.clearnet,
+		Hybrid:               *cf.hybrid,
+		Networks:             networks,
+		CentralitySamples:    *cf.centralitySamples,
+		Scorer:               s,
+		Include:              include,
+		Exclude:              exclude,
+		ExcludeClosed:        *cf.excludeClosed,
+		Uptime:               uptime,
+		MinUptime:            *cf.minUptime,
+		ASNs:                 asns,
+		UniqueASN:            *cf.uniqueASN,
+		UniqueSubnet:         *cf.uniqueSubnet,
+		MaxMedianOutboundFee: lightning.FeePPM(*cf.maxOutboundFee),
+		MaxMedianInboundFee:  lightning.FeePPM(*cf.maxInboundFee),
+		MinMedianChannelSize: lightning.Satoshi(*cf.minChannelSize),
+		MinEnabledPercent:    *cf.minEnabledPercent,
+		MinChannelAge:        *cf.minChannelAge,
+	}, nil
+}
+
 func main() {
 	cmdLog := log.New(os.Stderr, "raiju: ", 0)
 
 	rootFlagSet := flag.NewFlagSet("raiju", flag.ExitOnError)
 
 	// hooked up to ff with WithConfigFileFlag
-	var defaultConfigFile string
+	var defaultConfigFile, defaultDataDir string
 	if d, err := os.UserConfigDir(); err == nil {
 		defaultConfigFile = filepath.Join(d, "raiju", "config")
+		defaultDataDir = filepath.Join(d, "raiju")
 	}
 	rootFlagSet.String("config", defaultConfigFile, "configuration file path")
+	dataDir := rootFlagSet.String("data-dir", defaultDataDir, "Directory where raiju keeps state between runs")
 
 	// lnd flags
 	host := rootFlagSet.String("host", "localhost:10009", "LND host with port")
@@ -78,30 +381,94 @@ func main() {
 	liquidityThresholds := rootFlagSet.String("liquidity-thresholds", "85,15", "Comma separated local liquidity percent thresholds")
 	liquidityFees := rootFlagSet.String("liquidity-fees", "5,50,500", "Comma separated local liquidity-based fees PPM")
 	liquidityStickiness := rootFlagSet.Float64("liquidity-stickiness", 0, "Percent of a channel capacity beyond threshold to wait before changing fees from settings attempting to improve liquidity")
+	// candidates flags
+	output := rootFlagSet.String("output", "table", "Output format of command results (table, json, jsonl, csv)")
+
+	candidatesScorer := rootFlagSet.String("candidates-scorer", "distance", "Comma separated candidate scorers (distance, capacity, centrality, reliability, fees, diversity) with optional weights, e.g. distance:2,fees:1")
 
 	candidatesFlagSet := flag.NewFlagSet("candidates", flag.ExitOnError)
-	minCapacity := candidatesFlagSet.Int64("min-capacity", 1000000, "Minimum capacity of a node in satoshis")
-	minChannels := candidatesFlagSet.Int64("min-channels", 1, "Candidate must have at least this many channels")
-	minDistance := candidatesFlagSet.Int64("min-distance", 2, "Candidate must be at least this far away (0 is root node and 1 is direct connection)")
-	minDistantNeighbors := candidatesFlagSet.Int64("min-distant-neighbors", 0, "Candidate must have a minimum number of distant neighbors")
-	pubkey := candidatesFlagSet.String("pubkey", "", "Node to span out from, defaults to the connected node")
-	assume := candidatesFlagSet.String("assume", "", "Comma separated pubkeys to assume channels too")
+	candidatesFlags := newCandidatesFlags(candidatesFlagSet)
 	limit := candidatesFlagSet.Int64("limit", 100, "Number of results")
-	clearnet := candidatesFlagSet.Bool("clearnet", true, "Filter tor-only nodes")
+
+	explainFlagSet := flag.NewFlagSet("explain", flag.ExitOnError)
+	explainCandidatesFlags := newCandidatesFlags(explainFlagSet)
+
+	explainCmd := &ffcli.Command{
+		Name:       "explain",
+		ShortUsage: "raiju candidates explain [flags] <pubkey>",
+		ShortHelp:  "Explain why a node is or isn't a candidate",
+		LongHelp:   "Shows the node's shortest path from the root node, which of its neighbors are distant, which filters exclude it, and how the distance from the root node to the rest of the network would change with a channel to it. Accepts the same filters as the candidates command.",
+		FlagSet:    explainFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("explain takes one arg")
+			}
+
+			request, err := explainCandidatesFlags.request(*candidatesScorer, *dataDir)
+			if err != nil {
+				return err
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+			// an explanation is a single nested record
+			if p.Format() == view.CSV {
+				return errors.New("explain can't be output as csv")
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
+			if err != nil {
+				return err
+			}
+			defer services.Close()
+
+			c := lightning.NewLndClient(services, *network)
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
+
+			r := raiju.New(c, f)
+
+			explanation, err := r.Explain(ctx, request, lightning.PubKey(args[0]))
+			if err != nil {
+				return err
+			}
+
+			return p.Explanation(explanation)
+		},
+	}
 
 	candidatesCmd := &ffcli.Command{
-		Name:       "candidates",
-		ShortUsage: "raiju candidates",
-		ShortHelp:  "List candidate nodes by distance from node and centralization",
-		LongHelp:   "Nodes are listed in descending order based on a few calculated metrics. The dominant metric is distance from the root node. Next is 'distant neighbors' which is the number of direct neighbors a node has that are distant from the root node.",
-		FlagSet:    candidatesFlagSet,
+		Name:        "candidates",
+		ShortUsage:  "raiju candidates [flags] [<subcommand>]",
+		ShortHelp:   "List candidate nodes by distance from node and centralization",
+		LongHelp:    "Nodes are listed in descending order based on a few calculated metrics. The dominant metric is distance from the root node. Next is 'distant neighbors' which is the number of direct neighbors a node has that are distant from the root node. The ranking can be swapped out or combined with other scorers through the global candidates-scorer setting.",
+		FlagSet:     candidatesFlagSet,
+		Subcommands: []*ffcli.Command{explainCmd},
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) != 0 {
 				return errors.New("candidates doesn't take any arguments")
 			}
 
-			if *minDistance < 2 {
-				return errors.New("min-distance must be greater than 1")
+			request, err := candidatesFlags.request(*candidatesScorer, *dataDir)
+			if err != nil {
+				return err
+			}
+			request.Limit = *limit
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
 			}
 
 			cfg := &lndclient.LndServicesConfig{
@@ -125,35 +492,177 @@ func main() {
 
 			r := raiju.New(c, f)
 
-			// using FieldsFunc to handle empty string case correctly
-			a := strings.FieldsFunc(*assume, func(c rune) bool { return c == ',' })
-			assume := make([]lightning.PubKey, len(a))
-			for i, p := range a {
-				assume[i] = lightning.PubKey(p)
+			cmdLog.Printf("filtering candidates by capacity: %d, channels: %d, distance: %d, distant neighbors: %d\n", request.MinCapacity, request.MinChannels, request.MinDistance, request.MinDistantNeighbors)
+
+			candidates, err := r.Candidates(ctx, request)
+			if err != nil {
+				return err
 			}
 
-			request := raiju.CandidatesRequest{
-				PubKey:              lightning.PubKey(*pubkey),
-				MinCapacity:         lightning.Satoshi(*minCapacity),
-				MinChannels:         *minChannels,
-				MinDistance:         *minDistance,
-				MinDistantNeighbors: *minDistantNeighbors,
-				MinUpdated:          time.Now().Add(-2 * 24 * time.Hour),
-				Assume:              assume,
-				Limit:               *limit,
-				Clearnet:            *clearnet,
+			return p.Nodes(candidates)
+		},
+	}
+
+	planFlagSet := flag.NewFlagSet("plan", flag.ExitOnError)
+	planCandidatesFlags := newCandidatesFlags(planFlagSet)
+	planChannels := planFlagSet.Int("channels", 3, "Maximum number of channels to open")
+	planMinSize := planFlagSet.Int64("min-size", 1000000, "Minimum size of a channel in satoshis")
+	planMaxSize := planFlagSet.Int64("max-size", 5000000, "Maximum size of a channel in satoshis")
+	planBeam := planFlagSet.Int("beam", 1, "Number of partial plans to keep while searching, 1 is greedy")
+	planBatch := planFlagSet.Bool("batch", false, "Output the plan in lncli batchopenchannel JSON format")
+
+	planCmd := &ffcli.Command{
+		Name:       "plan",
+		ShortUsage: "raiju plan [flags] <budget>",
+		ShortHelp:  "Plan a batch of channels to open with a satoshi budget",
+		LongHelp:   "Picks candidates one at a time, assuming a channel to each pick before re-calculating the rest. A beam search keeps the best few partial plans at each step, judged by how close they bring the rest of the network. The budget is split evenly between the channels within the size limits. Accepts the same filters as the candidates command.",
+		FlagSet:    planFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("plan takes one arg")
 			}
 
-			cmdLog.Printf("filtering candidates by capacity: %d, channels: %d, distance: %d, distant neighbors: %d\n", request.MinCapacity, request.MinChannels, request.MinDistance, request.MinDistantNeighbors)
+			budget, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("unable to parse arg: %s", args[0])
+			}
 
-			candidates, err := r.Candidates(ctx, request)
+			request, err := planCandidatesFlags.request(*candidatesScorer, *dataDir)
+			if err != nil {
+				return err
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+			if *planBatch && p.Format() != view.Table {
+				return errors.New("batch is its own format and can't be combined with the output flag")
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
 			if err != nil {
 				return err
 			}
+			defer services.Close()
 
-			view.TableNodes(candidates)
+			c := lightning.NewLndClient(services, *network)
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			r := raiju.New(c, f)
+
+			cmdLog.Printf("planning up to %d channels with a budget of %d sats\n", *planChannels, budget)
+
+			plan, err := r.Plan(ctx, raiju.PlanRequest{
+				Candidates:     request,
+				Channels:       *planChannels,
+				Budget:         lightning.Satoshi(budget),
+				MinChannelSize: lightning.Satoshi(*planMinSize),
+				MaxChannelSize: lightning.Satoshi(*planMaxSize),
+				Beam:           *planBeam,
+			})
+			if err != nil {
+				return err
+			}
+
+			if *planBatch {
+				return p.BatchPlan(plan)
+			}
+
+			return p.Plan(plan)
+		},
+	}
+
+	openFlagSet := flag.NewFlagSet("open", flag.ExitOnError)
+	openPlan := openFlagSet.String("plan", "", "File containing a plan in the batch JSON format output by the plan command")
+	openSatPerVByte := openFlagSet.Uint64("sat-per-vbyte", 0, "Fee rate of the funding transaction, 0 lets the node estimate it")
+	openMaxSpend := openFlagSet.Int64("max-spend", 10000000, "Maximum total satoshis to commit to channels in this run")
+	openBatch := openFlagSet.Bool("batch", false, "Fund all the channels with a single PSBT transaction")
+	openYes := openFlagSet.Bool("yes", false, "Skip the confirmation prompt")
+
+	openCmd := &ffcli.Command{
+		Name:       "open",
+		ShortUsage: "raiju open [flags] [<pubkey>:<amount> ...]",
+		ShortHelp:  "Open channels to candidates",
+		LongHelp:   "Connects to each node at its announced addresses and opens a channel funded by the node's wallet. Channels are either listed as args or read from a plan file. Batched channels are funded by a single transaction which is only published if every peer accepts its channel. Opening channels spends on-chain funds, so the total is capped and confirmed before anything is opened.",
+		FlagSet:    openFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			var channels []lightning.NewChannel
+			var err error
+			switch {
+			case *openPlan != "" && len(args) != 0:
+				return errors.New("open takes either a plan or channel args, not both")
+			case *openPlan != "":
+				channels, err = readPlan(*openPlan)
+			default:
+				channels, err = parseChannels(args)
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(channels) == 0 {
+				return errors.New("no channels to open")
+			}
+
+			var total lightning.Satoshi
+			for _, c := range channels {
+				cmdLog.Printf("channel to %s of %d sats\n", c.PubKey, c.Amount)
+				total += c.Amount
+			}
+
+			if !*openYes {
+				ok, err := confirm(fmt.Sprintf("Open %d channels for a total of %d sats?", len(channels), total))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("open not confirmed")
+				}
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
+			if err != nil {
+				return err
+			}
+			defer services.Close()
+
+			c := lightning.NewLndClient(services, *network)
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
+
+			r := raiju.New(c, f)
+
+			txids, err := r.Open(ctx, raiju.OpenRequest{
+				Channels: channels,
+				FeeRate:  lightning.SatPerVByte(*openSatPerVByte),
+				MaxSpend: lightning.Satoshi(*openMaxSpend),
+				Batch:    *openBatch,
+			})
+			for _, txid := range txids {
+				cmdLog.Printf("funding transaction %s\n", txid)
+			}
+
+			return err
 		},
 	}
 
@@ -167,6 +676,11 @@ func main() {
 				return errors.New("fees does not take any args")
 			}
 
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+
 			cfg := &lndclient.LndServicesConfig{
 				LndAddress:         *host,
 				Network:            lndclient.Network(*network),
@@ -186,7 +700,9 @@ func main() {
 				return err
 			}
 
-			view.TableFees(f)
+			if p.Format() == view.Table {
+				p.Fees(f)
+			}
 
 			r := raiju.New(c, f)
 
@@ -198,26 +714,151 @@ func main() {
 			// listen for first update which sets the fees, then exit
 			select {
 			case u := <-uc:
-				for id, fee := range u {
-					cmdLog.Printf("channel %d updated to %f fee PPM", id, fee)
-				}
+				return p.FeeUpdates(u)
 			case err := <-ec:
 				return err
 			}
-
-			return nil
 		},
 	}
 
 	rebalanceFlagSet := flag.NewFlagSet("rebalance", flag.ExitOnError)
 	maxFeePPM := rebalanceFlagSet.Float64("max-fee-ppm", 0, "Override the default of low liquidity fee ppm based on global standard flag")
+	rebalanceMaxParts := rebalanceFlagSet.Uint("max-parts", 1, "Maximum number of parts a rebalance payment can be split into")
+	rebalanceConcurrency := rebalanceFlagSet.Int("concurrency", 1, "Number of channel pairs to rebalance at once")
+	rebalanceFeeBudget := rebalanceFlagSet.Int64("fee-budget", 0, "Maximum sats to spend on fees in this run, 0 is no limit")
+	rebalanceDailyFeeBudget := rebalanceFlagSet.Int64("daily-fee-budget", 0, "Maximum sats to spend on fees in the last day including earlier runs, 0 is no limit")
+	rebalanceProbe := rebalanceFlagSet.Bool("probe", false, "Probe routes to size payments before paying, requires mac-path")
+	rebalanceRoutes := rebalanceFlagSet.Bool("routes", false, "Pay along circular paths found over the graph instead of leaving routing to lnd, requires mac-path")
+	rebalanceBackoff := rebalanceFlagSet.Duration("backoff", 0, "Skip channels and pairs which didn't move any sats in their last run for this long, doubling with every such run in a row, 0 never skips")
+	rebalanceStrategy := rebalanceFlagSet.String("strategy", "value", "How to pair channels (value, random), value tries the most profitable pairs first and skips unprofitable ones")
+
+	historyFlagSet := flag.NewFlagSet("history", flag.ExitOnError)
+	historyFailures := historyFlagSet.Bool("failures", false, "List where rebalances fail instead of the success of each channel pair")
+
+	historyCmd := &ffcli.Command{
+		Name:       "history",
+		ShortUsage: "raiju rebalance history [flags]",
+		ShortHelp:  "Success rates of past rebalances by channel pair",
+		LongHelp:   "Every rebalance payment attempted by the rebalance and daemon commands is recorded in the data directory. Shows the success rate, sats moved, and fees paid of each channel pair, or with the failures flag the nodes and failure codes where payments most often fail.",
+		FlagSet:    historyFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return errors.New("history does not take any args")
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+
+			history, err := loadRebalances(*dataDir)
+			if err != nil {
+				return err
+			}
+
+			if *historyFailures {
+				return p.FailurePoints(history.FailurePoints())
+			}
+
+			return p.RebalancePairs(history.Pairs())
+		},
+	}
+
+	pairFlagSet := flag.NewFlagSet("pair", flag.ExitOnError)
+	pairMaxFeePPM := pairFlagSet.Float64("max-fee-ppm", 0, "Override the default of low liquidity fee ppm based on global standard flag")
+	pairMaxParts := pairFlagSet.Uint("max-parts", 1, "Maximum number of parts a rebalance payment can be split into")
+	pairMaxAttempts := pairFlagSet.Int("max-attempts", 10, "Maximum number of payments to attempt, 0 is no limit")
+	pairTimeout := pairFlagSet.Duration("timeout", 10*time.Minute, "Give up on the rebalance after this long, 0 is no limit")
+	pairProbe := pairFlagSet.Bool("probe", false, "Probe routes to size payments before paying, requires mac-path")
+	pairRoutes := pairFlagSet.Bool("routes", false, "Pay along circular paths found over the graph instead of leaving routing to lnd, requires mac-path")
+
+	pairCmd := &ffcli.Command{
+		Name:       "pair",
+		ShortUsage: "raiju rebalance pair [flags] <out-channel> <in-channel> <amount>",
+		ShortHelp:  "Move an amount of sats out one channel and back in another",
+		LongHelp:   "Circular rebalance between two specific channels, for when it is known exactly which liquidity needs to move. The amount is in sats and the channels are their IDs. Payments are tried in shrinking steps until the amount is moved, the max attempts are used up, or the timeout hits.",
+		FlagSet:    pairFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 3 {
+				return errors.New("pair takes three args")
+			}
+
+			var ids [2]lightning.ChannelID
+			for i, a := range args[:2] {
+				id, err := strconv.ParseUint(a, 10, 64)
+				if err != nil {
+					return fmt.Errorf("unable to parse channel ID: %s", a)
+				}
+				ids[i] = lightning.ChannelID(id)
+			}
+
+			amount, err := strconv.ParseInt(args[2], 10, 64)
+			if err != nil || amount <= 0 {
+				return fmt.Errorf("amount must be a positive number of sats: %s", args[2])
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
+			if err != nil {
+				return err
+			}
+			defer services.Close()
+
+			c, err := newLndClient(services, *network, *macPath, *pairProbe || *pairRoutes)
+			if err != nil {
+				return err
+			}
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
+
+			r := raiju.New(c, f)
+
+			cmdLog.Printf("rebalancing %d sats out of channel %d into channel %d\n", amount, ids[0], ids[1])
+			result, err := r.RebalancePair(ctx, ids[0], ids[1], raiju.RebalanceRequest{
+				Amount:      lightning.Satoshi(amount),
+				MaxFee:      lightning.FeePPM(*pairMaxFeePPM),
+				MaxParts:    uint32(*pairMaxParts),
+				MaxAttempts: *pairMaxAttempts,
+				Timeout:     *pairTimeout,
+				Probe:       *pairProbe,
+				Routes:      *pairRoutes,
+			})
+			if rerr := recordRebalances(*dataDir, result.Attempts); rerr != nil {
+				cmdLog.Println(rerr)
+			}
+			if perr := p.Attempts(result.Attempts); perr != nil {
+				return perr
+			}
+			if err != nil {
+				return err
+			}
+
+			cmdLog.Printf("moved %d of %d sats for %d sats in fees (%.0f ppm) in %s\n", result.Moved, amount, result.Fees, result.EffectivePPM(), result.Duration.Round(time.Second))
+
+			return nil
+		},
+	}
 
 	rebalanceCmd := &ffcli.Command{
-		Name:       "rebalance",
-		ShortUsage: "raiju rebalance <max-percent>",
-		ShortHelp:  "Send circular payment(s) to actively rebalance channels",
-		LongHelp:   "Attempts to move liquidity from the channels with the highest local liquidity to the lowest.",
-		FlagSet:    rebalanceFlagSet,
+		Name:        "rebalance",
+		ShortUsage:  "raiju rebalance [flags] <max-percent> | pair | history",
+		ShortHelp:   "Send circular payment(s) to actively rebalance channels",
+		LongHelp:    "Attempts to move liquidity from the channels with the highest local liquidity to the lowest.",
+		FlagSet:     rebalanceFlagSet,
+		Subcommands: []*ffcli.Command{historyCmd, pairCmd},
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) != 1 {
 				return errors.New("rebalance takes one arg")
@@ -225,7 +866,22 @@ func main() {
 
 			maxPercent, err := strconv.ParseFloat(args[0], 64)
 			if err != nil {
-				return fmt.Errorf("unable to parse arg: %s", args[1])
+				return fmt.Errorf("unable to parse arg: %s", args[0])
+			}
+
+			strategy, err := raiju.ParsePairStrategy(*rebalanceStrategy)
+			if err != nil {
+				return err
+			}
+
+			history, err := loadRebalances(*dataDir)
+			if err != nil {
+				return err
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
 			}
 
 			cfg := &lndclient.LndServicesConfig{
@@ -241,13 +897,18 @@ func main() {
 			}
 			defer services.Close()
 
-			c := lightning.NewLndClient(services, *network)
+			c, err := newLndClient(services, *network, *macPath, *rebalanceProbe || *rebalanceRoutes)
+			if err != nil {
+				return err
+			}
 			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
 			if err != nil {
 				return err
 			}
 
-			view.TableFees(f)
+			if p.Format() == view.Table {
+				p.Fees(f)
+			}
 
 			r := raiju.New(c, f)
 
@@ -258,23 +919,150 @@ func main() {
 			}
 
 			cmdLog.Println("Rebalancing channels...")
-			rebalanced, err := r.Rebalance(ctx, maxPercent, maxFee)
+			results, err := r.Rebalance(ctx, raiju.RebalanceRequest{
+				MaxPercent:     maxPercent,
+				MaxFee:         maxFee,
+				MaxParts:       uint32(*rebalanceMaxParts),
+				Strategy:       strategy,
+				History:        history,
+				Concurrency:    *rebalanceConcurrency,
+				FeeBudget:      lightning.Satoshi(*rebalanceFeeBudget),
+				DailyFeeBudget: lightning.Satoshi(*rebalanceDailyFeeBudget),
+				Probe:          *rebalanceProbe,
+				Routes:         *rebalanceRoutes,
+				Backoff:        *rebalanceBackoff,
+			})
+			if rerr := recordRebalances(*dataDir, results.Attempts()); rerr != nil {
+				cmdLog.Println(rerr)
+			}
+			// partial results are still worth showing if the run was cut short
+			if perr := p.RebalanceResults(results); perr != nil {
+				return perr
+			}
 			if err != nil {
 				return err
 			}
-			for id, percent := range rebalanced {
-				cmdLog.Printf("rebalanced %f percent of channel %d\n", percent, id)
-			}
+
+			total := results.Total()
+			cmdLog.Printf("moved %d sats across %d pairs for %d sats in fees (%.0f ppm)\n", total.Moved, len(results), total.Fees, total.EffectivePPM())
 
 			return nil
 		},
 	}
 
+	reaperCmd := &ffcli.Command{
+		Name:       "reaper",
+		ShortUsage: "raiju reaper",
+		ShortHelp:  "List inefficient channels which should be closed",
+		LongHelp:   "Channels which have not forwarded a payment in the last month are listed.",
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return errors.New("reaper does not take any args")
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
+			if err != nil {
+				return err
+			}
+			defer services.Close()
+
+			c := lightning.NewLndClient(services, *network)
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
+
+			r := raiju.New(c, f)
+
+			channels, err := r.Reaper(ctx)
+			if err != nil {
+				return err
+			}
+
+			return p.Channels(channels)
+		},
+	}
+
+	invoicesFlagSet := flag.NewFlagSet("invoices", flag.ExitOnError)
+	invoicesDays := invoicesFlagSet.Int("days", 30, "Number of days back to list settled invoices")
+	invoicesRebalances := invoicesFlagSet.Bool("rebalances", false, "Include the invoices paid by rebalances")
+
+	invoicesCmd := &ffcli.Command{
+		Name:       "invoices",
+		ShortUsage: "raiju invoices [flags]",
+		ShortHelp:  "List settled invoices, leaving out rebalances",
+		LongHelp:   "Invoices settled in the last days, newest first. The invoices raiju pays itself to rebalance channels are not revenue, so they are left out unless the rebalances flag is set.",
+		FlagSet:    invoicesFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return errors.New("invoices does not take any args")
+			}
+
+			p, err := newPrinter(*output)
+			if err != nil {
+				return err
+			}
+
+			cfg := &lndclient.LndServicesConfig{
+				LndAddress:         *host,
+				Network:            lndclient.Network(*network),
+				CustomMacaroonPath: *macPath,
+				TLSPath:            *tlsPath,
+				RPCTimeout:         rpcTimeout,
+			}
+			services, err := lndclient.NewLndServices(cfg)
+			if err != nil {
+				return err
+			}
+			defer services.Close()
+
+			c := lightning.NewLndClient(services, *network)
+			f, err := parseFees(*liquidityThresholds, *liquidityFees, *liquidityStickiness)
+			if err != nil {
+				return err
+			}
+
+			r := raiju.New(c, f)
+
+			invoices, err := r.Invoices(ctx, time.Now().AddDate(0, 0, -*invoicesDays), *invoicesRebalances)
+			if err != nil {
+				return err
+			}
+
+			return p.Invoices(invoices)
+		},
+	}
+
+	daemonFlagSet := flag.NewFlagSet("daemon", flag.ExitOnError)
+	daemonProbeInterval := daemonFlagSet.Duration("probe-interval", 0, "How often to probe the reachability of peers and candidates over clearnet, 0 disables probing")
+	daemonProbeLimit := daemonFlagSet.Int64("probe-limit", 100, "Number of top candidates to probe")
+	daemonMaxParts := daemonFlagSet.Uint("rebalance-max-parts", 1, "Maximum number of parts a rebalance payment can be split into")
+	daemonConcurrency := daemonFlagSet.Int("rebalance-concurrency", 1, "Number of channel pairs to rebalance at once")
+	daemonDailyFeeBudget := daemonFlagSet.Int64("rebalance-daily-fee-budget", 0, "Maximum sats to spend on rebalance fees in the last day, 0 is no limit")
+	daemonProbe := daemonFlagSet.Bool("rebalance-probe", false, "Probe routes to size rebalance payments before paying, requires mac-path")
+	daemonRoutes := daemonFlagSet.Bool("rebalance-routes", false, "Pay rebalances along circular paths found over the graph instead of leaving routing to lnd, requires mac-path")
+	daemonBackoff := daemonFlagSet.Duration("rebalance-backoff", 12*time.Hour, "Skip channels and pairs which didn't move any sats in their last run for this long, doubling with every such run in a row, 0 never skips")
+	daemonStrategy := daemonFlagSet.String("rebalance-strategy", "value", "How to pair channels (value, random), value tries the most profitable pairs first and skips unprofitable ones")
+	daemonCandidatesFlags := newCandidatesFlags(daemonFlagSet)
+
 	daemonCmd := &ffcli.Command{
 		Name:       "daemon",
-		ShortUsage: "raiju daemon",
+		ShortUsage: "raiju daemon [flags]",
 		ShortHelp:  "Daemon process running subcommands",
-		LongHelp:   "Long running service process to passively manage fees and periodically active rebalances.",
+		LongHelp:   "Long running service process to passively manage fees and periodically active rebalances. Optionally probes the reachability of peers and the top candidates, recording an uptime history which the candidates filters use. Accepts the same filters as the candidates command to pick which candidates are probed.",
+		FlagSet:    daemonFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) != 0 {
 				return errors.New("fees does not take any args")
@@ -285,7 +1073,75 @@ func main() {
 				return err
 			}
 
-			view.TableFees(f)
+			view.NewPrinter(os.Stdout, view.Table).Fees(f)
+
+			strategy, err := raiju.ParsePairStrategy(*daemonStrategy)
+			if err != nil {
+				return err
+			}
+			if (*daemonProbe || *daemonRoutes) && *macPath == "" {
+				return errRoutesMacaroon
+			}
+
+			// periodically probe reachability
+			if *daemonProbeInterval > 0 {
+				// fail fast on bad flags
+				if _, err := daemonCandidatesFlags.request(*candidatesScorer, *dataDir); err != nil {
+					return err
+				}
+
+				go func() {
+					for range time.Tick(*daemonProbeInterval) {
+						func() {
+							// re-built each run to refresh the time based filters
+							request, err := daemonCandidatesFlags.request(*candidatesScorer, *dataDir)
+							if err != nil {
+								cmdLog.Printf("Unable to build candidates request %s", err)
+								return
+							}
+							request.Limit = *daemonProbeLimit
+							// keep probing nodes the uptime filter excludes, otherwise they could never recover
+							request.MinUptime = 0
+
+							cfg := &lndclient.LndServicesConfig{
+								LndAddress:         *host,
+								Network:            lndclient.Network(*network),
+								CustomMacaroonPath: *macPath,
+								TLSPath:            *tlsPath,
+								RPCTimeout:         rpcTimeout,
+							}
+							services, err := lndclient.NewLndServices(cfg)
+							if err != nil {
+								cmdLog.Printf("Unable to connect to lnd in order to probe %s", err)
+								return
+							}
+							defer services.Close()
+
+							c := lightning.NewLndClient(services, *network)
+							r := raiju.New(c, f)
+							cmdLog.Println("Probing nodes...")
+							reachable, err := r.Probe(ctx, request)
+							if err != nil {
+								cmdLog.Printf("Unable to probe %s", err)
+								return
+							}
+
+							history := make(raiju.UptimeHistory)
+							err = store.Update(filepath.Join(*dataDir, uptimeFile), &history, func() error {
+								now := time.Now()
+								history.Record(reachable, now)
+								history.Prune(now.Add(-uptimeWindow))
+								return nil
+							})
+							if err != nil {
+								cmdLog.Printf("Unable to record uptime history %s", err)
+								return
+							}
+							cmdLog.Printf("probed %d nodes\n", len(reachable))
+						}()
+					}
+				}()
+			}
 
 			// periodically rebalance
 			go func() {
@@ -305,17 +1161,42 @@ func main() {
 						}
 						defer services.Close()
 
-						c := lightning.NewLndClient(services, *network)
+						c, err := newLndClient(services, *network, *macPath, *daemonProbe || *daemonRoutes)
+						if err != nil {
+							cmdLog.Println(err)
+							return
+						}
 						r := raiju.New(c, f)
+						history, err := loadRebalances(*dataDir)
+						if err != nil {
+							cmdLog.Println(err)
+						}
+
 						cmdLog.Println("Rebalancing channels...")
-						rebalanced, err := r.Rebalance(ctx, 5.0, f.RebalanceFee())
+						results, err := r.Rebalance(ctx, raiju.RebalanceRequest{
+							MaxPercent:     5.0,
+							MaxFee:         f.RebalanceFee(),
+							MaxParts:       uint32(*daemonMaxParts),
+							Strategy:       strategy,
+							History:        history,
+							Concurrency:    *daemonConcurrency,
+							DailyFeeBudget: lightning.Satoshi(*daemonDailyFeeBudget),
+							Probe:          *daemonProbe,
+							Routes:         *daemonRoutes,
+							Backoff:        *daemonBackoff,
+						})
+						if rerr := recordRebalances(*dataDir, results.Attempts()); rerr != nil {
+							cmdLog.Println(rerr)
+						}
+						for _, result := range results {
+							cmdLog.Printf("moved %d sats out of channel %d into channel %d for %d sats in fees (%.0f ppm) in %d attempts\n", result.Moved, result.Out, result.In, result.Fees, result.EffectivePPM(), len(result.Attempts))
+						}
+						total := results.Total()
+						cmdLog.Printf("moved %d sats across %d pairs for %d sats in fees (%.0f ppm)\n", total.Moved, len(results), total.Fees, total.EffectivePPM())
 						if err != nil {
 							cmdLog.Printf("Unable to to rebalance %s", err)
 							return
 						}
-						for id, percent := range rebalanced {
-							cmdLog.Printf("rebalanced %f percent of channel %d\n", percent, id)
-						}
 					}()
 				}
 			}()
@@ -352,8 +1233,6 @@ func main() {
 					return err
 				}
 			}
-
-			return nil
 		},
 	}
 
@@ -362,7 +1241,7 @@ func main() {
 		FlagSet:     rootFlagSet,
 		ShortHelp:   "Interactive dashboard",
 		LongHelp:    "If given no subcommand, fire up an interactive dashboard that uses the subcommands under the hood.",
-		Subcommands: []*ffcli.Command{candidatesCmd, daemonCmd, feesCmd, rebalanceCmd},
+		Subcommands: []*ffcli.Command{candidatesCmd, daemonCmd, feesCmd, invoicesCmd, openCmd, planCmd, reaperCmd, rebalanceCmd},
 		Options:     []ff.Option{ff.WithEnvVarPrefix("RAIJU"), ff.WithConfigFileFlag("config"), ff.WithConfigFileParser(ff.PlainParser), ff.WithAllowMissingConfigFile(true)},
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) != 0 {
@@ -396,18 +1275,23 @@ func main() {
 			flex.SetBorder(true).SetTitle("raiju")
 			app.SetRoot(flex, true)
 
-			viewChannels, err := view.ViewChannels(ctx, r)
+			viewLog := view.ViewLog()
+
+			viewChannels, err := view.ViewChannels(ctx, app, r, viewLog, func(attempts []raiju.RebalanceAttempt) error {
+				return recordRebalances(*dataDir, attempts)
+			})
 			if err != nil {
 				return err
 			}
 
-			viewCandidates, err := view.ViewCandidates(ctx, r)
+			viewCandidates, err := view.ViewCandidates(ctx, app, r, viewLog, filepath.Join(*dataDir, assumedFile))
 			if err != nil {
 				return err
 			}
 
 			flex.AddItem(viewChannels, 0, 3, true)
 			flex.AddItem(viewCandidates, 0, 1, true)
+			flex.AddItem(viewLog, 8, 0, false)
 
 			if err := app.Run(); err != nil {
 				return err
@@ -417,7 +1301,11 @@ func main() {
 		},
 	}
 
-	if err := root.ParseAndRun(context.Background(), os.Args[1:]); err != nil {
+	// interrupts cancel the command, giving it a chance to wrap up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := root.ParseAndRun(ctx, os.Args[1:]); err != nil {
 		// no need to output redundant message, just exit
 		if err == flag.ErrHelp {
 			os.Exit(1)
@@ -0,0 +1,107 @@
+package raiju
+
+import (
+	"net/netip"
+
+	"github.com/nyonson/raiju/asn"
+	"github.com/nyonson/raiju/lightning"
+)
+
+const (
+	// subnetBitsIPv4 groups IPv4 addresses likely run by the same operator
+	subnetBitsIPv4 = 24
+	// subnetBitsIPv6 groups IPv6 addresses likely run by the same operator, the typical site allocation
+	subnetBitsIPv6 = 48
+)
+
+// ips of the node's public IP addresses.
+func ips(node lightning.Node) []netip.Addr {
+	ips := make([]netip.Addr, 0, len(node.Addresses))
+	for _, a := range node.Addresses {
+		addr, err := lightning.ParseAddress(a)
+		if err != nil || (addr.Type != lightning.IPv4 && addr.Type != lightning.IPv6) {
+			continue
+		}
+
+		if ip, err := netip.ParseAddr(addr.Host); err == nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+// subnet the ip belongs to.
+func subnet(ip netip.Addr) netip.Prefix {
+	bits := subnetBitsIPv6
+	if ip.Is4() {
+		bits = subnetBitsIPv4
+	}
+
+	p, _ := ip.Prefix(bits)
+	return p
+}
+
+// networkDiversity counts the root nodes' peers which share an ASN or subnet with each candidate.
+func networkDiversity(nodes map[lightning.PubKey]*RelativeNode, roots []lightning.PubKey, candidates []RelativeNode, asns *asn.Database) {
+	peers := make(map[lightning.PubKey]bool)
+	for _, root := range roots {
+		if r, ok := nodes[root]; ok {
+			for _, p := range r.Neighbors {
+				peers[p] = true
+			}
+		}
+	}
+
+	// a peer with multiple addresses in one network only counts once
+	peerASNs := make(map[asn.ASN]map[lightning.PubKey]bool)
+	peerSubnets := make(map[netip.Prefix]map[lightning.PubKey]bool)
+	for p := range peers {
+		peer, ok := nodes[p]
+		if !ok {
+			continue
+		}
+
+		for _, ip := range ips(peer.Node) {
+			s := subnet(ip)
+			if peerSubnets[s] == nil {
+				peerSubnets[s] = make(map[lightning.PubKey]bool)
+			}
+			peerSubnets[s][p] = true
+
+			if asns == nil {
+				continue
+			}
+
+			if a, ok := asns.Lookup(ip); ok {
+				if peerASNs[a] == nil {
+					peerASNs[a] = make(map[lightning.PubKey]bool)
+				}
+				peerASNs[a][p] = true
+			}
+		}
+	}
+
+	for i := range candidates {
+		inASN := make(map[lightning.PubKey]bool)
+		inSubnet := make(map[lightning.PubKey]bool)
+		for _, ip := range ips(candidates[i].Node) {
+			for p := range peerSubnets[subnet(ip)] {
+				inSubnet[p] = true
+			}
+
+			if asns == nil {
+				continue
+			}
+
+			if a, ok := asns.Lookup(ip); ok {
+				for p := range peerASNs[a] {
+					inASN[p] = true
+				}
+			}
+		}
+
+		candidates[i].PeersInASN = int64(len(inASN))
+		candidates[i].PeersInSubnet = int64(len(inSubnet))
+	}
+}
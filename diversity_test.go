@@ -0,0 +1,94 @@
+package raiju
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/asn"
+	"github.com/nyonson/raiju/lightning"
+)
+
+func Test_networkDiversity(t *testing.T) {
+	asns, err := asn.Parse(strings.NewReader("44.0.0.0\t44.255.255.255\t64496\tZZ\tHOSTING\n"))
+	if err != nil {
+		t.Fatalf("asn.Parse() error = %v", err)
+	}
+
+	// root A has peers B and C, B is in the hosting ASN and C shares a subnet with D
+	nodes := map[lightning.PubKey]*RelativeNode{
+		pubKeyA: {Node: lightning.Node{PubKey: pubKeyA}, Neighbors: []lightning.PubKey{pubKeyB, pubKeyC}},
+		pubKeyB: {Node: lightning.Node{PubKey: pubKeyB, Addresses: []string{"44.1.1.1:9735", "44.1.1.2:9735"}}},
+		pubKeyC: {Node: lightning.Node{PubKey: pubKeyC, Addresses: []string{"45.1.1.1:9735"}}},
+	}
+	candidates := []RelativeNode{
+		{Node: lightning.Node{PubKey: pubKeyD, Addresses: []string{"45.1.1.200:9735"}}},
+		{Node: lightning.Node{PubKey: pubKeyE, Addresses: []string{"44.2.2.2:9735", torAddress}}},
+		{Node: lightning.Node{PubKey: pubKeyF, Addresses: []string{torAddress}}},
+	}
+
+	tests := []struct {
+		name string
+		asns *asn.Database
+		want [][2]int64
+	}{
+		{
+			name: "subnets and asns",
+			asns: asns,
+			want: [][2]int64{{0, 1}, {1, 0}, {0, 0}},
+		},
+		{
+			name: "only subnets without an asn database",
+			asns: nil,
+			want: [][2]int64{{0, 1}, {0, 0}, {0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := append([]RelativeNode{}, candidates...)
+			networkDiversity(nodes, []lightning.PubKey{pubKeyA}, cs, tt.asns)
+
+			got := make([][2]int64, len(cs))
+			for i, c := range cs {
+				got[i] = [2]int64{c.PeersInASN, c.PeersInSubnet}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("networkDiversity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaiju_Candidates_unique(t *testing.T) {
+	l := &lightningerMock{
+		DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+			// a linear network (A) <=> (B) <=> (C) <=> (D), with B and D in the same subnet
+			g := lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD)
+			g.Nodes[1].Addresses = []string{"45.1.1.1:9735"}
+			g.Nodes[3].Addresses = []string{"45.1.1.2:9735"}
+			return g, nil
+		},
+	}
+
+	r := Raiju{
+		l: l,
+	}
+	got, err := r.Candidates(context.Background(), CandidatesRequest{
+		PubKeys:      []lightning.PubKey{pubKeyA},
+		MinCapacity:  1,
+		MinChannels:  1,
+		MinDistance:  2,
+		MinUpdated:   updated.Add(time.Hour * -3),
+		Limit:        10,
+		UniqueSubnet: true,
+	})
+	if err != nil {
+		t.Fatalf("Raiju.Candidates() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0].PubKey != pubKeyC {
+		t.Errorf("Raiju.Candidates() = %v, want only C", got)
+	}
+}
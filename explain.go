@@ -0,0 +1,205 @@
+package raiju
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// exclusions are the reasons the request filters out the node, empty if it is a candidate.
+func exclusions(request CandidatesRequest, node RelativeNode) []string {
+	reasons := make([]string, 0)
+	exclude := func(format string, a ...any) {
+		reasons = append(reasons, fmt.Sprintf(format, a...))
+	}
+
+	if node.Capacity < request.MinCapacity {
+		exclude("capacity of %d sats is below the minimum of %d sats", node.Capacity, request.MinCapacity)
+	}
+
+	if node.Channels < request.MinChannels {
+		exclude("%d channels is below the minimum of %d", node.Channels, request.MinChannels)
+	}
+
+	if node.Distance < request.MinDistance {
+		exclude("distance of %d is below the minimum of %d", node.Distance, request.MinDistance)
+	}
+
+	if node.DistantNeigbors < request.MinDistantNeighbors {
+		exclude("%d distant neighbors is below the minimum of %d", node.DistantNeigbors, request.MinDistantNeighbors)
+	}
+
+	if !node.Updated.After(request.MinUpdated) {
+		exclude("last updated %s, not since %s", node.Updated.Format("2006-01-02"), request.MinUpdated.Format("2006-01-02"))
+	}
+
+	if !request.Include.Empty() && !request.Include.Match(node.Node) {
+		exclude("not on the include list")
+	}
+
+	if request.Exclude.Match(node.Node) {
+		exclude("on the exclude list, or has a pending or closed channel with the local node")
+	}
+
+	if uptime, ok := request.Uptime[node.PubKey]; ok && uptime < request.MinUptime {
+		exclude("uptime of %.1f%% is below the minimum of %.1f%%", uptime, request.MinUptime)
+	}
+
+	if request.Clearnet && !node.Clearnet() {
+		exclude("no public clearnet address")
+	}
+
+	if request.Hybrid && !node.Hybrid() {
+		exclude("not reachable over both tor and clearnet")
+	}
+
+	networks := node.Networks()
+	for _, n := range request.Networks {
+		if !networks[n] {
+			exclude("no %s address", n)
+		}
+	}
+
+	if request.UniqueASN && node.PeersInASN > 0 {
+		exclude("%d root peers are in the same ASN", node.PeersInASN)
+	}
+
+	if request.UniqueSubnet && node.PeersInSubnet > 0 {
+		exclude("%d root peers are in the same subnet", node.PeersInSubnet)
+	}
+
+	if request.MaxMedianOutboundFee > 0 && node.MedianOutboundFee > request.MaxMedianOutboundFee {
+		exclude("median outbound fee of %.0f PPM is above the maximum of %.0f PPM", node.MedianOutboundFee, request.MaxMedianOutboundFee)
+	}
+
+	if request.MaxMedianInboundFee > 0 && node.MedianInboundFee > request.MaxMedianInboundFee {
+		exclude("median inbound fee of %.0f PPM is above the maximum of %.0f PPM", node.MedianInboundFee, request.MaxMedianInboundFee)
+	}
+
+	if node.MedianChannelSize < request.MinMedianChannelSize {
+		exclude("median channel size of %d sats is below the minimum of %d sats", node.MedianChannelSize, request.MinMedianChannelSize)
+	}
+
+	if node.OldestChannelAge < request.MinChannelAge {
+		exclude("oldest channel of %d blocks is below the minimum of %d blocks", node.OldestChannelAge, request.MinChannelAge)
+	}
+
+	if node.Channels > 0 {
+		enabled := float64(node.Channels-node.DisabledChannels) / float64(node.Channels) * 100
+		if enabled < request.MinEnabledPercent {
+			exclude("%.1f%% of channels enabled is below the minimum of %.1f%%", enabled, request.MinEnabledPercent)
+		}
+	}
+
+	return reasons
+}
+
+// Explanation of a node's standing as a candidate.
+type Explanation struct {
+	RelativeNode
+	// Path of hops from the closest root to the node, empty if the node is unreachable
+	Path []lightning.Node
+	// DistantNeighbors are the node's neighbors which are far from the roots
+	DistantNeighbors []lightning.Node
+	// Exclusions are the reasons the node is filtered out, empty if it is a candidate
+	Exclusions []string
+	// Distances is the number of reachable nodes at each distance from the roots
+	Distances map[int64]int64
+	// AssumedDistances is the number of reachable nodes at each distance if a channel was opened to the node
+	AssumedDistances    map[int64]int64
+	MeanDistance        float64
+	AssumedMeanDistance float64
+}
+
+// Explain why a node is, or isn't, a candidate.
+func (r Raiju) Explain(ctx context.Context, request CandidatesRequest, pubKey lightning.PubKey) (Explanation, error) {
+	request, err := r.localize(ctx, request)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	channelGraph, err := r.l.DescribeGraph(ctx)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	nodes, err := relativeNodes(channelGraph, request)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	n, ok := nodes[pubKey]
+	if !ok {
+		return Explanation{}, fmt.Errorf("node %s is not in the graph", pubKey)
+	}
+
+	node := []RelativeNode{*n}
+	networkDiversity(nodes, request.PubKeys, node, request.ASNs)
+
+	assumed := request
+	assumed.Assume = append(append([]lightning.PubKey{}, request.Assume...), pubKey)
+	assumedNodes, err := relativeNodes(channelGraph, assumed)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	e := Explanation{
+		RelativeNode:        node[0],
+		Path:                path(nodes, pubKey),
+		DistantNeighbors:    make([]lightning.Node, 0),
+		Exclusions:          exclusions(request, node[0]),
+		Distances:           distances(nodes),
+		AssumedDistances:    distances(assumedNodes),
+		MeanDistance:        meanDistance(nodes),
+		AssumedMeanDistance: meanDistance(assumedNodes),
+	}
+
+	for _, neighbor := range n.Neighbors {
+		if nodes[neighbor].Distance > distantNeighborLimit {
+			e.DistantNeighbors = append(e.DistantNeighbors, nodes[neighbor].Node)
+		}
+	}
+
+	return e, nil
+}
+
+// path from the closest root to the node, walking back through neighbors one hop closer.
+func path(nodes map[lightning.PubKey]*RelativeNode, pubKey lightning.PubKey) []lightning.Node {
+	n := nodes[pubKey]
+	// unreachable nodes have no distance or root
+	if n.Root == "" {
+		return []lightning.Node{}
+	}
+
+	p := make([]lightning.Node, n.Distance+1)
+	p[n.Distance] = n.Node
+	for d := n.Distance; d > 0; d-- {
+		// sorted so the path is the same every time
+		neighbors := append([]lightning.PubKey{}, nodes[p[d].PubKey].Neighbors...)
+		sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+
+		for _, neighbor := range neighbors {
+			m := nodes[neighbor]
+			if m.Distance == d-1 && m.Root == n.Root {
+				p[d-1] = m.Node
+				break
+			}
+		}
+	}
+
+	return p
+}
+
+// distances histogram of reachable nodes.
+func distances(nodes map[lightning.PubKey]*RelativeNode) map[int64]int64 {
+	h := make(map[int64]int64)
+	for _, n := range nodes {
+		if n.Distance > 0 {
+			h[n.Distance]++
+		}
+	}
+
+	return h
+}
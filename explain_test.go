@@ -0,0 +1,79 @@
+package raiju
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_Explain(t *testing.T) {
+	l := &lightningerMock{
+		DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+			// a linear network (A) <=> (B) <=> (C) <=> (D) <=> (E)
+			return lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE), nil
+		},
+	}
+
+	r := Raiju{
+		l: l,
+	}
+	got, err := r.Explain(context.Background(), CandidatesRequest{
+		PubKeys:     []lightning.PubKey{pubKeyA},
+		MinChannels: 1,
+		MinDistance: 4,
+		MinUpdated:  updated.Add(time.Hour * -3),
+	}, pubKeyD)
+	if err != nil {
+		t.Fatalf("Raiju.Explain() error = %v", err)
+	}
+
+	if want := []lightning.PubKey{pubKeyA, pubKeyB, pubKeyC, pubKeyD}; !reflect.DeepEqual(pubKeys(got.Path), want) {
+		t.Errorf("Raiju.Explain() Path = %v, want %v", got.Path, want)
+	}
+
+	if want := []lightning.PubKey{pubKeyE}; !reflect.DeepEqual(pubKeys(got.DistantNeighbors), want) {
+		t.Errorf("Raiju.Explain() DistantNeighbors = %v, want %v", got.DistantNeighbors, want)
+	}
+
+	if want := []string{"distance of 3 is below the minimum of 4"}; !reflect.DeepEqual(got.Exclusions, want) {
+		t.Errorf("Raiju.Explain() Exclusions = %v, want %v", got.Exclusions, want)
+	}
+
+	if want := map[int64]int64{1: 1, 2: 1, 3: 1, 4: 1}; !reflect.DeepEqual(got.Distances, want) {
+		t.Errorf("Raiju.Explain() Distances = %v, want %v", got.Distances, want)
+	}
+
+	if want := map[int64]int64{1: 2, 2: 2}; !reflect.DeepEqual(got.AssumedDistances, want) {
+		t.Errorf("Raiju.Explain() AssumedDistances = %v, want %v", got.AssumedDistances, want)
+	}
+
+	if got.MeanDistance != 2.5 || got.AssumedMeanDistance != 1.5 {
+		t.Errorf("Raiju.Explain() mean distances = %v, %v, want 2.5, 1.5", got.MeanDistance, got.AssumedMeanDistance)
+	}
+}
+
+func pubKeys(nodes []lightning.Node) []lightning.PubKey {
+	keys := make([]lightning.PubKey, len(nodes))
+	for i, n := range nodes {
+		keys[i] = n.PubKey
+	}
+
+	return keys
+}
+
+func TestRaiju_Explain_missing(t *testing.T) {
+	r := Raiju{
+		l: &lightningerMock{
+			DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+				return lineGraph(pubKeyA, pubKeyB), nil
+			},
+		},
+	}
+
+	if _, err := r.Explain(context.Background(), CandidatesRequest{PubKeys: []lightning.PubKey{pubKeyA}}, pubKeyC); err == nil {
+		t.Errorf("Raiju.Explain() expected error for node not in graph")
+	}
+}
@@ -0,0 +1,272 @@
+package raiju
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// RebalanceAttempt of a single circular payment out one channel and back in another.
+type RebalanceAttempt struct {
+	Time   time.Time
+	Out    lightning.ChannelID
+	In     lightning.ChannelID
+	Amount lightning.Satoshi
+	Fee    lightning.Satoshi
+	// Route of the payment, the last failed one if it didn't succeed
+	Route     []lightning.Hop
+	Succeeded bool
+	// FailureCode of the HTLC which failed, empty if the payment never made it out
+	FailureCode string
+	// FailureSource node which failed the HTLC, empty if it failed locally
+	FailureSource lightning.PubKey
+	// Error of a failed attempt
+	Error string
+}
+
+// maxBackoff of a channel or pair which keeps failing.
+const maxBackoff = time.Hour * 24 * 7
+
+// Cooldown of a channel or pair of channels after rebalance runs.
+type Cooldown struct {
+	LastAttempt time.Time
+	// Failures of runs in a row without a successful payment
+	Failures int
+}
+
+// Until the cooldown is over, backing off from base and doubling with every failure in a row.
+func (c Cooldown) Until(base time.Duration) time.Time {
+	if c.Failures == 0 {
+		return c.LastAttempt
+	}
+
+	wait := base
+	for i := 1; i < c.Failures && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	return c.LastAttempt.Add(wait)
+}
+
+// record a run's outcome.
+func (c *Cooldown) record(last time.Time, succeeded bool) {
+	c.LastAttempt = last
+	if succeeded {
+		c.Failures = 0
+	} else {
+		c.Failures++
+	}
+}
+
+// RebalanceHistory of attempts, oldest first, and the cooldowns they left channels and pairs in.
+type RebalanceHistory struct {
+	Attempts         []RebalanceAttempt
+	ChannelCooldowns map[lightning.ChannelID]Cooldown
+	// PairCooldowns by out and then in channel
+	PairCooldowns map[lightning.ChannelID]map[lightning.ChannelID]Cooldown
+}
+
+// Record attempts of a rebalance run to the history.
+//
+// A pair which didn't move any sats in the run adds to its failures, and so does a channel which didn't move any with
+// any of its partners.
+func (h *RebalanceHistory) Record(attempts []RebalanceAttempt) {
+	h.Attempts = append(h.Attempts, attempts...)
+
+	if h.ChannelCooldowns == nil {
+		h.ChannelCooldowns = make(map[lightning.ChannelID]Cooldown)
+	}
+	if h.PairCooldowns == nil {
+		h.PairCooldowns = make(map[lightning.ChannelID]map[lightning.ChannelID]Cooldown)
+	}
+
+	type outcome struct {
+		last      time.Time
+		succeeded bool
+	}
+	channels := make(map[lightning.ChannelID]outcome)
+	pairs := make(map[[2]lightning.ChannelID]outcome)
+	for _, a := range attempts {
+		for _, id := range []lightning.ChannelID{a.Out, a.In} {
+			o := channels[id]
+			channels[id] = outcome{last: latest(o.last, a.Time), succeeded: o.succeeded || a.Succeeded}
+		}
+		k := [2]lightning.ChannelID{a.Out, a.In}
+		o := pairs[k]
+		pairs[k] = outcome{last: latest(o.last, a.Time), succeeded: o.succeeded || a.Succeeded}
+	}
+
+	for id, o := range channels {
+		c := h.ChannelCooldowns[id]
+		c.record(o.last, o.succeeded)
+		h.ChannelCooldowns[id] = c
+	}
+	for k, o := range pairs {
+		if h.PairCooldowns[k[0]] == nil {
+			h.PairCooldowns[k[0]] = make(map[lightning.ChannelID]Cooldown)
+		}
+		c := h.PairCooldowns[k[0]][k[1]]
+		c.record(o.last, o.succeeded)
+		h.PairCooldowns[k[0]][k[1]] = c
+	}
+}
+
+// latest of two times.
+func latest(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// coolingDown is true if either channel or the pair is still backing off from failures at now.
+func (h RebalanceHistory) coolingDown(out lightning.ChannelID, in lightning.ChannelID, now time.Time, base time.Duration) bool {
+	cooldowns := []Cooldown{h.ChannelCooldowns[out], h.ChannelCooldowns[in], h.PairCooldowns[out][in]}
+	for _, c := range cooldowns {
+		if now.Before(c.Until(base)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Prune attempts and cooldowns older than before.
+func (h *RebalanceHistory) Prune(before time.Time) {
+	kept := h.Attempts[:0]
+	for _, a := range h.Attempts {
+		if !a.Time.Before(before) {
+			kept = append(kept, a)
+		}
+	}
+	h.Attempts = kept
+
+	for id, c := range h.ChannelCooldowns {
+		if c.LastAttempt.Before(before) {
+			delete(h.ChannelCooldowns, id)
+		}
+	}
+	for out, ins := range h.PairCooldowns {
+		for in, c := range ins {
+			if c.LastAttempt.Before(before) {
+				delete(ins, in)
+			}
+		}
+		if len(ins) == 0 {
+			delete(h.PairCooldowns, out)
+		}
+	}
+}
+
+// FeesSince paid by successful attempts after since.
+func (h RebalanceHistory) FeesSince(since time.Time) lightning.Satoshi {
+	var fees lightning.Satoshi
+	for _, a := range h.Attempts {
+		if a.Succeeded && a.Time.After(since) {
+			fees += a.Fee
+		}
+	}
+
+	return fees
+}
+
+// PairStats of the rebalance attempts between a pair of channels.
+type PairStats struct {
+	Out       lightning.ChannelID
+	In        lightning.ChannelID
+	Attempts  int64
+	Successes int64
+	Moved     lightning.Satoshi
+	Fees      lightning.Satoshi
+}
+
+// SuccessRate percent of the attempts.
+func (p PairStats) SuccessRate() float64 {
+	if p.Attempts == 0 {
+		return 0
+	}
+
+	return float64(p.Successes) / float64(p.Attempts) * 100
+}
+
+// Pairs stats of every channel pair attempted, sorted by out and then in channel.
+func (h RebalanceHistory) Pairs() []PairStats {
+	type pair struct {
+		out lightning.ChannelID
+		in  lightning.ChannelID
+	}
+
+	stats := make(map[pair]*PairStats)
+	for _, a := range h.Attempts {
+		k := pair{a.Out, a.In}
+		s, ok := stats[k]
+		if !ok {
+			s = &PairStats{Out: a.Out, In: a.In}
+			stats[k] = s
+		}
+
+		s.Attempts++
+		if a.Succeeded {
+			s.Successes++
+			s.Moved += a.Amount
+			s.Fees += a.Fee
+		}
+	}
+
+	pairs := make([]PairStats, 0, len(stats))
+	for _, s := range stats {
+		pairs = append(pairs, *s)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Out != pairs[j].Out {
+			return pairs[i].Out < pairs[j].Out
+		}
+		return pairs[i].In < pairs[j].In
+	})
+
+	return pairs
+}
+
+// FailurePoint where rebalance payments fail.
+type FailurePoint struct {
+	// Node which failed the payments, empty if they failed locally
+	Node     lightning.PubKey
+	Code     string
+	Failures int64
+}
+
+// FailurePoints of failed attempts, most common first.
+func (h RebalanceHistory) FailurePoints() []FailurePoint {
+	type point struct {
+		node lightning.PubKey
+		code string
+	}
+
+	counts := make(map[point]int64)
+	for _, a := range h.Attempts {
+		if a.Succeeded {
+			continue
+		}
+		counts[point{a.FailureSource, a.FailureCode}]++
+	}
+
+	points := make([]FailurePoint, 0, len(counts))
+	for p, c := range counts {
+		points = append(points, FailurePoint{Node: p.node, Code: p.code, Failures: c})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Failures != points[j].Failures {
+			return points[i].Failures > points[j].Failures
+		}
+		if points[i].Node != points[j].Node {
+			return points[i].Node < points[j].Node
+		}
+		return points[i].Code < points[j].Code
+	})
+
+	return points
+}
@@ -0,0 +1,198 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRebalanceHistory_Pairs(t *testing.T) {
+	h := RebalanceHistory{
+		Attempts: []RebalanceAttempt{
+			{Out: 2, In: 1, Amount: 10, Succeeded: false},
+			{Out: 1, In: 2, Amount: 10, Fee: 1, Succeeded: true},
+			{Out: 1, In: 2, Amount: 10, Succeeded: false},
+			{Out: 1, In: 2, Amount: 5, Fee: 1, Succeeded: true},
+		},
+	}
+
+	want := []PairStats{
+		{Out: 1, In: 2, Attempts: 3, Successes: 2, Moved: 15, Fees: 2},
+		{Out: 2, In: 1, Attempts: 1},
+	}
+	got := h.Pairs()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RebalanceHistory.Pairs() = %v, want %v", got, want)
+	}
+	if rate := got[0].SuccessRate(); rate < 66.6 || rate > 66.7 {
+		t.Errorf("PairStats.SuccessRate() = %v, want 66.6", rate)
+	}
+}
+
+func TestRebalanceHistory_FailurePoints(t *testing.T) {
+	h := RebalanceHistory{
+		Attempts: []RebalanceAttempt{
+			{FailureSource: pubKeyB, FailureCode: "TEMPORARY_CHANNEL_FAILURE"},
+			{FailureSource: pubKeyC, FailureCode: "FEE_INSUFFICIENT"},
+			{FailureSource: pubKeyC, FailureCode: "FEE_INSUFFICIENT"},
+			{Succeeded: true},
+		},
+	}
+
+	want := []FailurePoint{
+		{Node: pubKeyC, Code: "FEE_INSUFFICIENT", Failures: 2},
+		{Node: pubKeyB, Code: "TEMPORARY_CHANNEL_FAILURE", Failures: 1},
+	}
+	if got := h.FailurePoints(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RebalanceHistory.FailurePoints() = %v, want %v", got, want)
+	}
+}
+
+func TestRebalanceHistory_Prune(t *testing.T) {
+	now := time.Now()
+	h := RebalanceHistory{
+		Attempts: []RebalanceAttempt{
+			{Time: now.Add(-2 * time.Hour), Out: 1},
+			{Time: now, Out: 2},
+		},
+	}
+
+	h.Prune(now.Add(-time.Hour))
+
+	if len(h.Attempts) != 1 || h.Attempts[0].Out != 2 {
+		t.Errorf("RebalanceHistory.Prune() = %v, want only the recent attempt", h.Attempts)
+	}
+}
+
+func TestRaiju_RebalancePair_attempts(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	payments := 0
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				return lightning.Invoice(""), nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				return lightning.Channel{
+					Edge:         lightning.Edge{Capacity: 1000},
+					ChannelID:    channelID,
+					LocalBalance: 1000,
+					RemoteNode:   lightning.Node{PubKey: pubKeyC},
+				}, nil
+			},
+			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+				payments++
+				if payments == 1 {
+					return lightning.Payment{
+						Route:         []lightning.Hop{{ChannelID: 1, PubKey: pubKeyB}},
+						FailureCode:   "TEMPORARY_CHANNEL_FAILURE",
+						FailureSource: pubKeyB,
+					}, errors.New("payment failed")
+				}
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	result, err := r.RebalancePair(context.Background(), 1, 2, RebalanceRequest{MaxPercent: 1})
+	attempts := result.Attempts
+	if err != nil {
+		t.Fatalf("Raiju.RebalancePair() error = %v", err)
+	}
+
+	// a failure lowers the step, so two smaller payments make up the percent
+	if len(attempts) != 3 {
+		t.Fatalf("Raiju.RebalancePair() attempts = %v, want 3", attempts)
+	}
+	if attempts[0].Succeeded || attempts[0].Amount != 10 || attempts[0].FailureSource != pubKeyB || attempts[0].Error == "" || attempts[0].In != 2 {
+		t.Errorf("Raiju.RebalancePair() first attempt = %+v, want failure at B", attempts[0])
+	}
+	for _, a := range attempts[1:] {
+		if !a.Succeeded || a.Fee != 1 || a.Amount != 5 {
+			t.Errorf("Raiju.RebalancePair() attempt = %+v, want success of 5 sats", a)
+		}
+	}
+}
+
+func TestCooldown_Until(t *testing.T) {
+	last := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Time
+	}{
+		{
+			name: "no failures",
+			want: last,
+		},
+		{
+			name:     "one failure waits the base",
+			failures: 1,
+			want:     last.Add(time.Hour),
+		},
+		{
+			name:     "doubles with every failure",
+			failures: 3,
+			want:     last.Add(4 * time.Hour),
+		},
+		{
+			name:     "capped",
+			failures: 100,
+			want:     last.Add(maxBackoff),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Cooldown{LastAttempt: last, Failures: tt.failures}
+			if got := c.Until(time.Hour); !got.Equal(tt.want) {
+				t.Errorf("Cooldown.Until() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebalanceHistory_Record_cooldowns(t *testing.T) {
+	now := time.Now()
+	h := RebalanceHistory{}
+
+	// two runs where 1 never makes it into 2, but does into 3
+	h.Record([]RebalanceAttempt{
+		{Time: now, Out: 1, In: 2},
+		{Time: now, Out: 1, In: 3, Succeeded: true},
+	})
+	h.Record([]RebalanceAttempt{
+		{Time: now, Out: 1, In: 2},
+	})
+
+	if c := h.PairCooldowns[1][2]; c.Failures != 2 {
+		t.Errorf("RebalanceHistory.Record() pair failures = %v, want 2", c.Failures)
+	}
+	if c := h.ChannelCooldowns[2]; c.Failures != 2 {
+		t.Errorf("RebalanceHistory.Record() channel failures = %v, want 2", c.Failures)
+	}
+	if c := h.ChannelCooldowns[3]; c.Failures != 0 {
+		t.Errorf("RebalanceHistory.Record() channel failures = %v, want 0", c.Failures)
+	}
+
+	if !h.coolingDown(1, 2, now.Add(time.Hour), time.Hour) {
+		t.Error("RebalanceHistory.coolingDown() = false, want the failing pair to back off")
+	}
+	if h.coolingDown(1, 2, now.Add(3*time.Hour), time.Hour) {
+		t.Error("RebalanceHistory.coolingDown() = true, want the backoff to be over")
+	}
+	if h.coolingDown(4, 3, now, time.Hour) {
+		t.Error("RebalanceHistory.coolingDown() = true, want an untouched pair to be ready")
+	}
+
+	h.Prune(now.Add(time.Minute))
+	if len(h.ChannelCooldowns) != 0 || len(h.PairCooldowns) != 0 {
+		t.Errorf("RebalanceHistory.Prune() left cooldowns %v %v", h.ChannelCooldowns, h.PairCooldowns)
+	}
+}
@@ -0,0 +1,180 @@
+package lightning
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// AddressType is the network a node is reachable on.
+type AddressType int
+
+const (
+	// IPv4 public address
+	IPv4 AddressType = iota + 1
+	// IPv6 public address
+	IPv6
+	// TorV3 onion service, v2 onion services are no longer supported by tor
+	TorV3
+	// I2P base32 destination
+	I2P
+	// DNS hostname
+	DNS
+)
+
+var addressTypeNames = map[AddressType]string{
+	IPv4:  "ipv4",
+	IPv6:  "ipv6",
+	TorV3: "torv3",
+	I2P:   "i2p",
+	DNS:   "dns",
+}
+
+// String name of the address type.
+func (t AddressType) String() string {
+	if n, ok := addressTypeNames[t]; ok {
+		return n
+	}
+
+	return "unknown"
+}
+
+// Clearnet is true if the address type is reachable without an anonymity network.
+func (t AddressType) Clearnet() bool {
+	return t == IPv4 || t == IPv6 || t == DNS
+}
+
+// ParseAddressType by name.
+func ParseAddressType(name string) (AddressType, error) {
+	for t, n := range addressTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown address type: %s", name)
+}
+
+const (
+	// torV3Length is the length of a base32 encoded v3 onion service key, checksum, and version
+	torV3Length = 56
+	// i2pLength is the length of a base32 encoded I2P destination hash
+	i2pLength = 52
+)
+
+// Address a node is reachable at.
+type Address struct {
+	Host string
+	Port int
+	Type AddressType
+}
+
+// ParseAddress in host[:port] form, rejecting addresses which are not publicly reachable.
+func ParseAddress(address string) (Address, error) {
+	host, port := address, 0
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		n, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return Address{}, fmt.Errorf("invalid port: %s", p)
+		}
+		host, port = h, int(n)
+	}
+
+	if host == "" {
+		return Address{}, errors.New("empty host")
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return parseIP(ip, port)
+	}
+
+	host = strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(host, ".onion"):
+		if !base32Label(strings.TrimSuffix(host, ".onion"), torV3Length) {
+			return Address{}, fmt.Errorf("not a tor v3 address: %s", host)
+		}
+		return Address{Host: host, Port: port, Type: TorV3}, nil
+	case strings.HasSuffix(host, ".b32.i2p"):
+		if !base32Label(strings.TrimSuffix(host, ".b32.i2p"), i2pLength) {
+			return Address{}, fmt.Errorf("not an i2p address: %s", host)
+		}
+		return Address{Host: host, Port: port, Type: I2P}, nil
+	case hostname(host):
+		return Address{Host: host, Port: port, Type: DNS}, nil
+	}
+
+	return Address{}, fmt.Errorf("unknown address: %s", address)
+}
+
+// parseIP classifies an IP, rejecting ranges which aren't publicly routable.
+func parseIP(ip netip.Addr, port int) (Address, error) {
+	ip = ip.Unmap()
+
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip) {
+		return Address{}, fmt.Errorf("not a public address: %s", ip)
+	}
+
+	t := IPv6
+	if ip.Is4() {
+		t = IPv4
+	}
+
+	return Address{Host: ip.String(), Port: port, Type: t}, nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by netip's private check.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// base32Label is true if s is a single label of the given length in the RFC 4648 base32 alphabet.
+func base32Label(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '2' && c <= '7') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hostname is true if s is a fully qualified DNS name with an alphabetic top level domain.
+func hostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if len(s) > 253 || s == "localhost" || strings.HasSuffix(s, ".localhost") || strings.HasSuffix(s, ".local") {
+		return false
+	}
+
+	labels := strings.Split(s, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, l := range labels {
+		if len(l) == 0 || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+			return false
+		}
+
+		for _, c := range l {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	// rules out things which look like mangled IPs
+	for _, c := range labels[len(labels)-1] {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package lightning
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    Address
+		wantErr bool
+	}{
+		{
+			name:    "ipv4",
+			address: "123.123.123.123:9735",
+			want:    Address{Host: "123.123.123.123", Port: 9735, Type: IPv4},
+		},
+		{
+			name:    "ipv6",
+			address: "[2001:db8::1]:9735",
+			want:    Address{Host: "2001:db8::1", Port: 9735, Type: IPv6},
+		},
+		{
+			name:    "ipv4 mapped ipv6 is ipv4",
+			address: "[::ffff:123.123.123.123]:9735",
+			want:    Address{Host: "123.123.123.123", Port: 9735, Type: IPv4},
+		},
+		{
+			name:    "tor v3",
+			address: "axlvvynqvvz3f5u3dfhtsyxzeqttivnw2awas3rxniu5uvoqrlvrvgid.onion:9735",
+			want:    Address{Host: "axlvvynqvvz3f5u3dfhtsyxzeqttivnw2awas3rxniu5uvoqrlvrvgid.onion", Port: 9735, Type: TorV3},
+		},
+		{
+			name:    "i2p",
+			address: "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p",
+			want:    Address{Host: "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p", Port: 0, Type: I2P},
+		},
+		{
+			name:    "dns",
+			address: "Node.Example.com:9735",
+			want:    Address{Host: "node.example.com", Port: 9735, Type: DNS},
+		},
+		{
+			name:    "tor v2 is no longer valid",
+			address: "expyuzz4wqqyqhjn.onion:9735",
+			wantErr: true,
+		},
+		{
+			name:    "private",
+			address: "192.168.1.2:9735",
+			wantErr: true,
+		},
+		{
+			name:    "loopback",
+			address: "[::1]:9735",
+			wantErr: true,
+		},
+		{
+			name:    "carrier nat",
+			address: "100.64.1.1:9735",
+			wantErr: true,
+		},
+		{
+			name:    "localhost",
+			address: "localhost:9735",
+			wantErr: true,
+		},
+		{
+			name:    "bad port",
+			address: "123.123.123.123:99999",
+			wantErr: true,
+		},
+		{
+			name:    "garbage",
+			address: "not an address",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
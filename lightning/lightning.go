@@ -2,7 +2,6 @@
 package lightning
 
 import (
-	"strings"
 	"time"
 )
 
@@ -39,6 +38,9 @@ func (f FeePPM) Rate() float64 {
 	return float64(f) / 1000000
 }
 
+// SatPerVByte is an on-chain fee rate.
+type SatPerVByte uint64
+
 // Forward routing event.
 type Forward struct {
 	Timestamp  time.Time
@@ -54,43 +56,85 @@ type Node struct {
 	Addresses []string
 }
 
+// Networks the node has a valid public address on.
+func (n Node) Networks() map[AddressType]bool {
+	networks := make(map[AddressType]bool)
+	for _, a := range n.Addresses {
+		if addr, err := ParseAddress(a); err == nil {
+			networks[addr.Type] = true
+		}
+	}
+
+	return networks
+}
+
 // Clearnet is true if node has a clearnet address.
 func (n Node) Clearnet() bool {
-	clearnet := false
-
-	for _, a := range n.Addresses {
-		// simple check filtering tor addresses
-		if !strings.Contains(a, "onion") {
-			clearnet = true
+	for t := range n.Networks() {
+		if t.Clearnet() {
+			return true
 		}
+	}
+
+	return false
+}
+
+// Hybrid is true if node is reachable over both tor and clearnet.
+func (n Node) Hybrid() bool {
+	return n.Networks()[TorV3] && n.Clearnet()
+}
+
+// RoutingPolicy of a node forwarding payments out through an edge.
+type RoutingPolicy struct {
+	FeeRate  FeePPM
+	BaseFee  MilliSatoshi
+	Disabled bool
+}
 
+// Fee of forwarding amount as a rate, including the base fee.
+func (p RoutingPolicy) Fee(amount Satoshi) FeePPM {
+	if amount <= 0 {
+		return p.FeeRate
 	}
 
-	return clearnet
+	return p.FeeRate + FeePPM(float64(p.BaseFee)/float64(amount.Millis())*1_000_000)
 }
 
 // Edge between nodes in the Lightning Network.
 type Edge struct {
-	Capacity Satoshi
-	Node1    PubKey
-	Node2    PubKey
+	Capacity    Satoshi
+	Node1       PubKey
+	Node2       PubKey
+	Node1Policy *RoutingPolicy
+	Node2Policy *RoutingPolicy
+	// FundingHeight of the block the channel's funding transaction confirmed in
+	FundingHeight uint32
 }
 
 // Graph of nodes and edges of the Lightning Network.
 type Graph struct {
 	Nodes []Node
 	Edges []Edge
+	// Height of the best block when the graph was described
+	Height uint32
+}
+
+// fundingHeight of a channel, encoded in the first three bytes of its short channel ID.
+func fundingHeight(channelID uint64) uint32 {
+	return uint32(channelID >> 40)
 }
 
 // Channel between local and remote node.
 type Channel struct {
 	Edge
-	ChannelID     ChannelID
-	LocalBalance  Satoshi
-	LocalFee      FeePPM
-	RemoteBalance Satoshi
-	RemoteNode    Node
-	Private       bool
+	ChannelID    ChannelID
+	LocalBalance Satoshi
+	LocalFee     FeePPM
+	// LocalFeeUpdated is when the local routing policy last changed
+	LocalFeeUpdated time.Time
+	RemoteBalance   Satoshi
+	RemoteNode      Node
+	Private         bool
 }
 
 // Liquidity percent of the channel that is local.
@@ -101,6 +145,68 @@ func (c Channel) Liquidity() float64 {
 // Channels of node.
 type Channels []Channel
 
+// NewChannel to open with a peer.
+type NewChannel struct {
+	PubKey PubKey
+	Amount Satoshi
+}
+
+// Hop of a payment route, the channel taken to reach the node.
+type Hop struct {
+	ChannelID ChannelID
+	PubKey    PubKey
+}
+
+// SettledInvoice paid to the node.
+type SettledInvoice struct {
+	Memo    string
+	Amount  Satoshi
+	Settled time.Time
+}
+
+// Payment sent over the Lightning Network.
+type Payment struct {
+	Fee Satoshi
+	// Route of the settled HTLC, or the last failed one if none settled
+	Route []Hop
+	// FailureCode of the last failed HTLC, empty if the payment succeeded
+	FailureCode string
+	// FailureSource is the node which failed the last HTLC, empty if it failed locally
+	FailureSource PubKey
+}
+
+// RouteHop of a route along with what it forwards.
+type RouteHop struct {
+	Hop
+	// Forward amount the hop passes along to the next one
+	Forward MilliSatoshi
+	// Fee the hop charges to forward
+	Fee MilliSatoshi
+	// Expiry of the hop's HTLC
+	Expiry uint32
+}
+
+// Route of a payment from the local node, the last hop is the destination.
+type Route struct {
+	Hops []RouteHop
+	// Amount delivered to the destination
+	Amount MilliSatoshi
+	// Fee paid to the hops along the way
+	Fee MilliSatoshi
+	// TimeLock of the first hop's HTLC
+	TimeLock uint32
+}
+
+// Probe of a route with a payment which can never settle.
+type Probe struct {
+	// Reached is true if the payment made it to the destination, so the route can carry the amount
+	Reached bool
+	// FailureCode of the HTLC
+	FailureCode string
+	// FailureSource node which failed the HTLC, empty if it failed locally
+	FailureSource PubKey
+}
+
 // Info of a node.
 type Info struct {
 	PubKey PubKey
@@ -2,6 +2,7 @@
 package lightning
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -94,6 +95,16 @@ func TestNode_Clearnet(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "private addresses are not clearnet",
+			fields: fields{
+				PubKey:    "A",
+				Alias:     "A",
+				Updated:   time.Now(),
+				Addresses: []string{"10.0.0.1:9735", "axlvvynqvvz3f5u3dfhtsyxzeqttivnw2awas3rxniu5uvoqrlvrvgid.onion:9735"},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -110,6 +121,41 @@ func TestNode_Clearnet(t *testing.T) {
 	}
 }
 
+func TestNode_Networks(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []string
+		want      map[AddressType]bool
+		hybrid    bool
+	}{
+		{
+			name:      "hybrid node",
+			addresses: []string{"123.123.123.123:9735", "[2001:db8::1]:9735", "axlvvynqvvz3f5u3dfhtsyxzeqttivnw2awas3rxniu5uvoqrlvrvgid.onion:9735"},
+			want:      map[AddressType]bool{IPv4: true, IPv6: true, TorV3: true},
+			hybrid:    true,
+		},
+		{
+			name:      "invalid addresses are ignored",
+			addresses: []string{"127.0.0.1:9735", "expyuzz4wqqyqhjn.onion:9735"},
+			want:      map[AddressType]bool{},
+			hybrid:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Node{
+				Addresses: tt.addresses,
+			}
+			if got := n.Networks(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Node.Networks() = %v, want %v", got, tt.want)
+			}
+			if got := n.Hybrid(); got != tt.hybrid {
+				t.Errorf("Node.Hybrid() = %v, want %v", got, tt.hybrid)
+			}
+		})
+	}
+}
+
 func TestChannel_Liquidity(t *testing.T) {
 	type fields struct {
 		Edge          Edge
@@ -1,28 +1,39 @@
 package lightning
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wtxmgr"
 	"github.com/lightninglabs/lndclient"
+	invpkg "github.com/lightningnetwork/lnd/invoices"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/routing/route"
 	"github.com/lightningnetwork/lnd/zpay32"
+	"google.golang.org/grpc/metadata"
 )
 
-//go:generate moq -stub -skip-ensure -out lnd_mock_test.go . channeler router invoicer
+// PaymentTimeout of a single payment before lnd gives up on it.
+const PaymentTimeout = 60 * time.Second
+
+//go:generate moq -stub -skip-ensure -out lnd_mock_test.go . channeler router invoicer walleter pather
 
 // channeler is the minimum channel requirements from LND.
 type channeler interface {
@@ -35,6 +46,14 @@ type channeler interface {
 		includeChannels bool) (*lndclient.NodeInfo, error)
 	ListChannels(ctx context.Context, activeOnly, publicOnly bool) ([]lndclient.ChannelInfo, error)
 	UpdateChanPolicy(ctx context.Context, req lndclient.PolicyUpdateRequest, chanPoint *wire.OutPoint) error
+	Connect(ctx context.Context, peer route.Vertex, host string, permanent bool) error
+	OpenChannel(ctx context.Context, peer route.Vertex, localSat, pushSat btcutil.Amount, private bool,
+		opts ...lndclient.OpenChannelOption) (*wire.OutPoint, error)
+	OpenChannelStream(ctx context.Context, peer route.Vertex, localSat, pushSat btcutil.Amount, private bool,
+		opts ...lndclient.OpenChannelOption) (<-chan *lndclient.OpenStatusUpdate, <-chan error, error)
+	FundingStateStep(ctx context.Context, req *lnrpc.FundingTransitionMsg) (*lnrpc.FundingStateStepResp, error)
+	ClosedChannels(ctx context.Context) ([]lndclient.ClosedChannel, error)
+	PendingChannels(ctx context.Context) (*lndclient.PendingChannels, error)
 }
 
 // router is the minimum routing requirements from LND.
@@ -43,29 +62,113 @@ type router interface {
 	SubscribeHtlcEvents(ctx context.Context) (<-chan *routerrpc.HtlcEvent, <-chan error, error)
 }
 
-// invoicer is the minimum routing requirements from LND.
+// invoicer is the minimum invoice requirements from LND.
 type invoicer interface {
 	AddInvoice(ctx context.Context, in *invoicesrpc.AddInvoiceData) (lntypes.Hash, string, error)
+	CancelInvoice(ctx context.Context, hash lntypes.Hash) error
+	ListInvoices(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error)
+}
+
+// invoices splits the invoice calls between the main lightning service and the invoices sub-server.
+type invoices struct {
+	lndclient.LightningClient
+	lndclient.InvoicesClient
+}
+
+func (i invoices) AddInvoice(ctx context.Context, in *invoicesrpc.AddInvoiceData) (lntypes.Hash, string, error) {
+	return i.LightningClient.AddInvoice(ctx, in)
+}
+
+func (i invoices) CancelInvoice(ctx context.Context, hash lntypes.Hash) error {
+	return i.InvoicesClient.CancelInvoice(ctx, hash)
+}
+
+func (i invoices) ListInvoices(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error) {
+	return i.LightningClient.ListInvoices(ctx, req)
+}
+
+// walleter is the minimum on-chain wallet requirements from LND.
+type walleter interface {
+	FundPsbt(ctx context.Context, req *walletrpc.FundPsbtRequest) (*psbt.Packet, int32, []*walletrpc.UtxoLease, error)
+	FinalizePsbt(ctx context.Context, packet *psbt.Packet, account string) (*psbt.Packet, *wire.MsgTx, error)
+	ReleaseOutput(ctx context.Context, lockID wtxmgr.LockID, op wire.OutPoint) error
+}
+
+// pather is the minimum route requirements from LND which lndclient doesn't wrap.
+type pather interface {
+	QueryRoutes(ctx context.Context, in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error)
+	SendToRouteV2(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error)
+	BuildRoute(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error)
+	DeletePayment(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error)
 }
 
 // NewLndClient backed by a single LND lightning node.
 func NewLndClient(s *lndclient.GrpcLndServices, network string) LndClient {
 	return LndClient{
 		c:       s.Client,
-		i:       s.Client,
+		i:       invoices{LightningClient: s.Client, InvoicesClient: s.Invoices},
 		r:       s.Router,
+		w:       s.WalletKit,
 		network: network,
 	}
 }
 
+// NewLndClientWithMacaroon backed by a single LND lightning node, which can also query and probe routes.
+//
+// lndclient doesn't wrap the route RPCs, so they are called directly and authenticated with the macaroon at macPath.
+func NewLndClientWithMacaroon(s *lndclient.GrpcLndServices, network string, macPath string) (LndClient, error) {
+	mac, err := os.ReadFile(macPath)
+	if err != nil {
+		return LndClient{}, fmt.Errorf("unable to read macaroon: %w", err)
+	}
+
+	l := NewLndClient(s, network)
+	l.p = lndRPC{
+		lightning: lnrpc.NewLightningClient(s.ClientConn),
+		router:    routerrpc.NewRouterClient(s.ClientConn),
+		macaroon:  hex.EncodeToString(mac),
+	}
+
+	return l, nil
+}
+
 // LndClient client backed by LND node.
 type LndClient struct {
 	c       channeler
 	r       router
 	i       invoicer
+	w       walleter
+	p       pather
 	network string
 }
 
+// lndRPC calls LND's RPCs directly, authenticated with a macaroon.
+type lndRPC struct {
+	lightning lnrpc.LightningClient
+	router    routerrpc.RouterClient
+	macaroon  string
+}
+
+func (r lndRPC) auth(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "macaroon", r.macaroon)
+}
+
+func (r lndRPC) QueryRoutes(ctx context.Context, in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error) {
+	return r.lightning.QueryRoutes(r.auth(ctx), in)
+}
+
+func (r lndRPC) SendToRouteV2(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error) {
+	return r.router.SendToRouteV2(r.auth(ctx), in)
+}
+
+func (r lndRPC) BuildRoute(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error) {
+	return r.router.BuildRoute(r.auth(ctx), in)
+}
+
+func (r lndRPC) DeletePayment(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error) {
+	return r.lightning.DeletePayment(r.auth(ctx), in)
+}
+
 // GetInfo of local node.
 func (l LndClient) GetInfo(ctx context.Context) (*Info, error) {
 	i, err := l.c.GetInfo(ctx)
@@ -89,6 +192,11 @@ func (l LndClient) DescribeGraph(ctx context.Context) (*Graph, error) {
 		return &Graph{}, err
 	}
 
+	info, err := l.c.GetInfo(ctx)
+	if err != nil {
+		return &Graph{}, err
+	}
+
 	// marshall nodes
 	nodes := make([]Node, len(g.Nodes))
 	for i, n := range g.Nodes {
@@ -104,20 +212,37 @@ func (l LndClient) DescribeGraph(ctx context.Context) (*Graph, error) {
 	edges := make([]Edge, len(g.Edges))
 	for i, e := range g.Edges {
 		edges[i] = Edge{
-			Capacity: Satoshi(e.Capacity.ToUnit(btcutil.AmountSatoshi)),
-			Node1:    PubKey(e.Node1.String()),
-			Node2:    PubKey(e.Node2.String()),
+			Capacity:      Satoshi(e.Capacity.ToUnit(btcutil.AmountSatoshi)),
+			Node1:         PubKey(e.Node1.String()),
+			Node2:         PubKey(e.Node2.String()),
+			Node1Policy:   getRoutingPolicy(e.Node1Policy),
+			Node2Policy:   getRoutingPolicy(e.Node2Policy),
+			FundingHeight: fundingHeight(e.ChannelID),
 		}
 	}
 
 	graph := &Graph{
-		Nodes: nodes,
-		Edges: edges,
+		Nodes:  nodes,
+		Edges:  edges,
+		Height: info.BlockHeight,
 	}
 
 	return graph, nil
 }
 
+// getRoutingPolicy of an edge direction, nil if the node hasn't published one.
+func getRoutingPolicy(policy *lndclient.RoutingPolicy) *RoutingPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &RoutingPolicy{
+		FeeRate:  FeePPM(policy.FeeRateMilliMsat),
+		BaseFee:  MilliSatoshi(policy.FeeBaseMsat),
+		Disabled: policy.Disabled,
+	}
+}
+
 func getRemotePubkey(local *lndclient.Info, edge *lndclient.ChannelEdge) route.Vertex {
 	remotePubkey := edge.Node1
 	if local.IdentityPubkey == remotePubkey {
@@ -126,15 +251,15 @@ func getRemotePubkey(local *lndclient.Info, edge *lndclient.ChannelEdge) route.V
 	return remotePubkey
 }
 
-func getRemoteFeePPM(local *lndclient.Info, edge *lndclient.ChannelEdge) (FeePPM, error) {
+// getLocalPolicy of the local node's side of the channel.
+func getLocalPolicy(local *lndclient.Info, edge *lndclient.ChannelEdge) (*lndclient.RoutingPolicy, error) {
 	if edge.Node1Policy == nil || edge.Node2Policy == nil {
-		return 0, fmt.Errorf("channel node policy is missing in channel edge: %v", edge)
+		return nil, fmt.Errorf("channel node policy is missing in channel edge: %v", edge)
 	}
-	localFee := FeePPM(edge.Node2Policy.FeeRateMilliMsat)
 	if local.IdentityPubkey == edge.Node1 {
-		localFee = FeePPM(edge.Node1Policy.FeeRateMilliMsat)
+		return edge.Node1Policy, nil
 	}
-	return localFee, nil
+	return edge.Node2Policy, nil
 }
 
 // GetChannel with ID.
@@ -152,7 +277,7 @@ func (l LndClient) GetChannel(ctx context.Context, channelID ChannelID) (Channel
 
 	remotePubkey := getRemotePubkey(local, ce)
 
-	localFee, err := getRemoteFeePPM(local, ce)
+	policy, err := getLocalPolicy(local, ce)
 	if err != nil {
 		return Channel{}, err
 	}
@@ -168,8 +293,9 @@ func (l LndClient) GetChannel(ctx context.Context, channelID ChannelID) (Channel
 			Node1:    PubKey(ce.Node1.String()),
 			Node2:    PubKey(ce.Node2.String()),
 		},
-		ChannelID: ChannelID(ce.ChannelID),
-		LocalFee:  localFee,
+		ChannelID:       ChannelID(ce.ChannelID),
+		LocalFee:        FeePPM(policy.FeeRateMilliMsat),
+		LocalFeeUpdated: policy.LastUpdate,
 		RemoteNode: Node{
 			PubKey:    PubKey(remote.PubKey.String()),
 			Alias:     remote.Alias,
@@ -216,7 +342,7 @@ func (l LndClient) ListChannels(ctx context.Context) (Channels, error) {
 
 		remotePubkey := getRemotePubkey(local, ce)
 
-		localFee, err := getRemoteFeePPM(local, ce)
+		policy, err := getLocalPolicy(local, ce)
 		if err != nil {
 			return nil, err
 		}
@@ -232,10 +358,11 @@ func (l LndClient) ListChannels(ctx context.Context) (Channels, error) {
 				Node1:    PubKey(ce.Node1.String()),
 				Node2:    PubKey(ce.Node2.String()),
 			},
-			ChannelID:     ChannelID(ci.ChannelID),
-			LocalBalance:  Satoshi(ci.LocalBalance.ToUnit(btcutil.AmountSatoshi)),
-			LocalFee:      localFee,
-			RemoteBalance: Satoshi(ci.RemoteBalance.ToUnit(btcutil.AmountSatoshi)),
+			ChannelID:       ChannelID(ci.ChannelID),
+			LocalBalance:    Satoshi(ci.LocalBalance.ToUnit(btcutil.AmountSatoshi)),
+			LocalFee:        FeePPM(policy.FeeRateMilliMsat),
+			LocalFeeUpdated: policy.LastUpdate,
+			RemoteBalance:   Satoshi(ci.RemoteBalance.ToUnit(btcutil.AmountSatoshi)),
 			RemoteNode: Node{
 				PubKey:    PubKey(remote.PubKey.String()),
 				Alias:     remote.Alias,
@@ -249,6 +376,42 @@ func (l LndClient) ListChannels(ctx context.Context) (Channels, error) {
 	return channels, nil
 }
 
+// ClosedPeers of the local node, nodes which it had a channel with in the past.
+func (l LndClient) ClosedPeers(ctx context.Context) ([]PubKey, error) {
+	closed, err := l.c.ClosedChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]PubKey, len(closed))
+	for i, c := range closed {
+		peers[i] = PubKey(c.PubKeyBytes.String())
+	}
+
+	return peers, nil
+}
+
+// PendingPeers of the local node, nodes which it has a channel opening or closing with.
+func (l LndClient) PendingPeers(ctx context.Context) ([]PubKey, error) {
+	pending, err := l.c.PendingChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]PubKey, 0, len(pending.PendingOpen)+len(pending.PendingForceClose)+len(pending.WaitingClose))
+	for _, c := range pending.PendingOpen {
+		peers = append(peers, PubKey(c.PubKeyBytes.String()))
+	}
+	for _, c := range pending.PendingForceClose {
+		peers = append(peers, PubKey(c.PubKeyBytes.String()))
+	}
+	for _, c := range pending.WaitingClose {
+		peers = append(peers, PubKey(c.PubKeyBytes.String()))
+	}
+
+	return peers, nil
+}
+
 // SetFees for channel with rate in ppm.
 func (l LndClient) SetFees(ctx context.Context, channelID ChannelID, fee FeePPM, maxHTLC MilliSatoshi) error {
 	ce, err := l.c.GetChanInfo(ctx, uint64(channelID))
@@ -271,30 +434,93 @@ func (l LndClient) SetFees(ctx context.Context, channelID ChannelID, fee FeePPM,
 	return l.c.UpdateChanPolicy(ctx, req, outpoint)
 }
 
-// AddInvoice of amount.
-func (l LndClient) AddInvoice(ctx context.Context, amount Satoshi) (Invoice, error) {
+// AddInvoice of amount with a memo, which expires after expiry rounded up to the second.
+func (l LndClient) AddInvoice(ctx context.Context, amount Satoshi, memo string, expiry time.Duration) (Invoice, error) {
 	in := &invoicesrpc.AddInvoiceData{
-		Value: lnwire.NewMSatFromSatoshis(btcutil.Amount(amount)),
+		Memo:   memo,
+		Value:  lnwire.NewMSatFromSatoshis(btcutil.Amount(amount)),
+		Expiry: int64((expiry + time.Second - 1) / time.Second),
 	}
 	_, invoice, err := l.i.AddInvoice(ctx, in)
 	return Invoice(invoice), err
 }
 
+// CancelInvoice which hasn't been paid, so it can't be paid later.
+func (l LndClient) CancelInvoice(ctx context.Context, invoice Invoice) error {
+	i, err := l.decodeInvoice(invoice)
+	if err != nil {
+		return err
+	}
+	if i.PaymentHash == nil {
+		return errors.New("invoice has no payment hash")
+	}
+
+	if err := l.i.CancelInvoice(ctx, lntypes.Hash(*i.PaymentHash)); err != nil {
+		return fmt.Errorf("unable to cancel invoice: %w", err)
+	}
+
+	return nil
+}
+
+// invoicesPage of invoices pulled per call.
+const invoicesPage = 1000
+
+// SettledInvoices of the node since the time given, newest first.
+//
+// Invoices are paged through from the newest until one created before since, so an invoice created before since but
+// settled after it is missed.
+func (l LndClient) SettledInvoices(ctx context.Context, since time.Time) ([]SettledInvoice, error) {
+	settled := make([]SettledInvoice, 0)
+	offset := uint64(0)
+	for {
+		res, err := l.i.ListInvoices(ctx, lndclient.ListInvoicesRequest{
+			MaxInvoices: invoicesPage,
+			Offset:      offset,
+			Reversed:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list invoices: %w", err)
+		}
+
+		// pages are in ascending order even when reversed
+		older := false
+		for j := len(res.Invoices) - 1; j >= 0; j-- {
+			i := res.Invoices[j]
+			if i.CreationDate.Before(since) {
+				older = true
+			}
+			if i.State != invpkg.ContractSettled || i.SettleDate.Before(since) {
+				continue
+			}
+
+			settled = append(settled, SettledInvoice{
+				Memo:    i.Memo,
+				Amount:  Satoshi(i.AmountPaid.ToSatoshis()),
+				Settled: i.SettleDate,
+			})
+		}
+
+		if older || len(res.Invoices) < invoicesPage || res.FirstIndexOffset <= 1 {
+			return settled, nil
+		}
+		offset = res.FirstIndexOffset
+	}
+}
+
 // SendPayment to pay for invoice.
-func (l LndClient) SendPayment(ctx context.Context, invoice Invoice, outChannelID ChannelID, lastHopPubKey PubKey, maxFee FeePPM) (Satoshi, error) {
+//
+// A failed payment returns the details of the last failed HTLC along with an error. The payment is split into at most
+// maxParts HTLCs, zero leaves it up to lnd's default of a single part.
+func (l LndClient) SendPayment(ctx context.Context, invoice Invoice, outChannelID ChannelID, lastHopPubKey PubKey, maxFee FeePPM, maxParts uint32) (Payment, error) {
 	lhpk, err := route.NewVertexFromStr(string(lastHopPubKey))
 	if err != nil {
-		return 0, err
+		return Payment{}, err
 	}
 
 	// decode invoice to get amount in millisats and calculate max fee from ppm
-	params, err := lndclient.Network(l.network).ChainParams()
+	i, err := l.decodeInvoice(invoice)
 	if err != nil {
-		return 0, err
-	}
-	i, err := zpay32.Decode(string(invoice), params)
-	if err != nil {
-		return 0, err
+		return Payment{}, err
 	}
 	maxFeeMsat := uint64(float64(*i.MilliSat) * maxFee.Rate())
 
@@ -304,23 +530,298 @@ func (l LndClient) SendPayment(ctx context.Context, invoice Invoice, outChannelI
 		OutgoingChanIds:  []uint64{uint64(outChannelID)},
 		LastHopPubkey:    &lhpk,
 		AllowSelfPayment: true,
-		Timeout:          time.Duration(60) * time.Second,
+		Timeout:          PaymentTimeout,
+		MaxParts:         maxParts,
 	}
 	status, error, err := l.r.SendPayment(ctx, request)
 	if err != nil {
-		return 0, err
+		return Payment{}, err
 	}
 
 	for {
 		select {
 		case s := <-status:
-			if s.State == lnrpc.Payment_SUCCEEDED {
-				return Satoshi(s.Fee.ToSatoshis()), nil
+			switch s.State {
+			case lnrpc.Payment_SUCCEEDED:
+				return payment(s), nil
+			case lnrpc.Payment_FAILED:
+				return payment(s), fmt.Errorf("payment failed: %s", s.FailureReason)
 			}
 		case e := <-error:
-			return 0, fmt.Errorf("error paying invoice: %w", e)
+			return Payment{}, fmt.Errorf("error paying invoice: %w", e)
+		}
+	}
+}
+
+// payment details from the final status of a payment.
+func payment(s lndclient.PaymentStatus) Payment {
+	p := Payment{
+		Fee: Satoshi(s.Fee.ToSatoshis()),
+	}
+
+	// prefer the settled attempt, otherwise the last one tried
+	var attempt *lndclient.HtlcAttempt
+	for _, h := range s.Htlcs {
+		if h.Status == lnrpc.HTLCAttempt_SUCCEEDED {
+			attempt = h
+			break
+		}
+		attempt = h
+	}
+	if attempt == nil {
+		return p
+	}
+
+	if attempt.Route != nil {
+		for _, h := range attempt.Route.Hops {
+			p.Route = append(p.Route, Hop{
+				ChannelID: ChannelID(h.ChanId),
+				PubKey:    PubKey(h.PubKey),
+			})
+		}
+	}
+
+	if attempt.Status != lnrpc.HTLCAttempt_SUCCEEDED && attempt.Failure != nil {
+		p.FailureCode = attempt.Failure.Code.String()
+		// index zero is the local node, the rest line up with the route's hops
+		if i := int(attempt.Failure.FailureSourceIndex); i > 0 && i <= len(p.Route) {
+			p.FailureSource = p.Route[i-1].PubKey
+		}
+	}
+
+	return p
+}
+
+// errNoPather is returned by the route methods of a client created without a macaroon.
+var errNoPather = errors.New("routes require a client created with a macaroon")
+
+// QueryRoute for a circular payment of amount out the channel and back in through the last hop.
+func (l LndClient) QueryRoute(ctx context.Context, amount Satoshi, outChannelID ChannelID, lastHopPubKey PubKey, maxFee FeePPM) (Route, error) {
+	if l.p == nil {
+		return Route{}, errNoPather
+	}
+
+	info, err := l.c.GetInfo(ctx)
+	if err != nil {
+		return Route{}, err
+	}
+
+	lhpk, err := route.NewVertexFromStr(string(lastHopPubKey))
+	if err != nil {
+		return Route{}, err
+	}
+
+	resp, err := l.p.QueryRoutes(ctx, &lnrpc.QueryRoutesRequest{
+		PubKey:         hex.EncodeToString(info.IdentityPubkey[:]),
+		AmtMsat:        int64(amount.Millis()),
+		OutgoingChanId: uint64(outChannelID),
+		LastHopPubkey:  lhpk[:],
+		FeeLimit: &lnrpc.FeeLimit{
+			Limit: &lnrpc.FeeLimit_FixedMsat{
+				FixedMsat: int64(float64(amount.Millis()) * maxFee.Rate()),
+			},
+		},
+		UseMissionControl: true,
+	})
+	if err != nil {
+		return Route{}, fmt.Errorf("unable to query route: %w", err)
+	}
+	if len(resp.Routes) == 0 {
+		return Route{}, errors.New("no route found")
+	}
+
+	return newRoute(resp.Routes[0]), nil
+}
+
+// ProbeRoute with a random payment hash which can never settle.
+//
+// If the payment makes it to the destination, it is failed with unknown payment details, meaning the route can carry
+// the amount without spending a thing.
+func (l LndClient) ProbeRoute(ctx context.Context, r Route) (Probe, error) {
+	if l.p == nil {
+		return Probe{}, errNoPather
+	}
+
+	hash := make([]byte, 32)
+	if _, err := rand.Read(hash); err != nil {
+		return Probe{}, fmt.Errorf("unable to create probe hash: %w", err)
+	}
+
+	// any failure, temporary or not, fails the payment so it can be deleted
+	attempt, err := l.p.SendToRouteV2(ctx, &routerrpc.SendToRouteRequest{
+		PaymentHash: hash,
+		Route:       rpcRoute(r),
+	})
+	if err != nil {
+		return Probe{}, fmt.Errorf("unable to probe route: %w", err)
+	}
+	// probes would otherwise pile up in lnd's payments, best effort since a leftover is only clutter
+	_, _ = l.p.DeletePayment(ctx, &lnrpc.DeletePaymentRequest{PaymentHash: hash})
+	if attempt.Failure == nil {
+		return Probe{}, errors.New("probe was not failed")
+	}
+
+	probe := Probe{
+		FailureCode: attempt.Failure.Code.String(),
+	}
+	// index zero is the local node, the rest line up with the route's hops
+	if i := int(attempt.Failure.FailureSourceIndex); i > 0 && i <= len(r.Hops) {
+		probe.FailureSource = r.Hops[i-1].PubKey
+		probe.Reached = i == len(r.Hops) && attempt.Failure.Code == lnrpc.Failure_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS
+	}
+
+	return probe, nil
+}
+
+// BuildRoute to pay the invoice out the channel and through the hops, the last of which is the destination.
+//
+// lnd picks the channels between the hops and works out the fees and time locks.
+func (l LndClient) BuildRoute(ctx context.Context, invoice Invoice, outChannelID ChannelID, hops []PubKey) (Route, error) {
+	if l.p == nil {
+		return Route{}, errNoPather
+	}
+
+	i, err := l.decodeInvoice(invoice)
+	if err != nil {
+		return Route{}, err
+	}
+
+	pubKeys := make([][]byte, len(hops))
+	for j, h := range hops {
+		v, err := route.NewVertexFromStr(string(h))
+		if err != nil {
+			return Route{}, err
+		}
+		pubKeys[j] = v[:]
+	}
+
+	resp, err := l.p.BuildRoute(ctx, &routerrpc.BuildRouteRequest{
+		AmtMsat:        int64(*i.MilliSat),
+		FinalCltvDelta: int32(i.MinFinalCLTVExpiry()),
+		OutgoingChanId: uint64(outChannelID),
+		HopPubkeys:     pubKeys,
+	})
+	if err != nil {
+		return Route{}, fmt.Errorf("unable to build route: %w", err)
+	}
+
+	return newRoute(resp.Route), nil
+}
+
+// SendToRoute pays the invoice along the route.
+//
+// A failed payment returns the failure details along with an error.
+func (l LndClient) SendToRoute(ctx context.Context, invoice Invoice, r Route) (Payment, error) {
+	if l.p == nil {
+		return Payment{}, errNoPather
+	}
+
+	i, err := l.decodeInvoice(invoice)
+	if err != nil {
+		return Payment{}, err
+	}
+	if i.PaymentHash == nil || i.PaymentAddr == nil || len(r.Hops) == 0 {
+		return Payment{}, errors.New("unable to pay invoice without a payment hash and address")
+	}
+
+	rr := rpcRoute(r)
+	// the destination requires the invoice's payment address
+	rr.Hops[len(rr.Hops)-1].MppRecord = &lnrpc.MPPRecord{
+		PaymentAddr:  i.PaymentAddr[:],
+		TotalAmtMsat: int64(r.Amount),
+	}
+
+	attempt, err := l.p.SendToRouteV2(ctx, &routerrpc.SendToRouteRequest{
+		PaymentHash: i.PaymentHash[:],
+		Route:       rr,
+	})
+	if err != nil {
+		return Payment{}, fmt.Errorf("unable to send to route: %w", err)
+	}
+
+	p := Payment{}
+	for _, h := range r.Hops {
+		p.Route = append(p.Route, h.Hop)
+	}
+
+	if attempt.Status == lnrpc.HTLCAttempt_SUCCEEDED {
+		// round up so a fraction of a sat still counts against fee budgets
+		p.Fee = Satoshi((r.Fee + 999) / 1000)
+		return p, nil
+	}
+
+	if attempt.Failure != nil {
+		p.FailureCode = attempt.Failure.Code.String()
+		// index zero is the local node, the rest line up with the route's hops
+		if j := int(attempt.Failure.FailureSourceIndex); j > 0 && j <= len(p.Route) {
+			p.FailureSource = p.Route[j-1].PubKey
 		}
 	}
+
+	return p, fmt.Errorf("payment failed: %s", p.FailureCode)
+}
+
+// decodeInvoice for the client's network.
+func (l LndClient) decodeInvoice(invoice Invoice) (*zpay32.Invoice, error) {
+	params, err := lndclient.Network(l.network).ChainParams()
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := zpay32.Decode(string(invoice), params)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode invoice: %w", err)
+	}
+	if i.MilliSat == nil {
+		return nil, errors.New("invoice has no amount")
+	}
+
+	return i, nil
+}
+
+// newRoute from lnd's route.
+func newRoute(r *lnrpc.Route) Route {
+	hops := make([]RouteHop, len(r.Hops))
+	for i, h := range r.Hops {
+		hops[i] = RouteHop{
+			Hop: Hop{
+				ChannelID: ChannelID(h.ChanId),
+				PubKey:    PubKey(h.PubKey),
+			},
+			Forward: MilliSatoshi(h.AmtToForwardMsat),
+			Fee:     MilliSatoshi(h.FeeMsat),
+			Expiry:  h.Expiry,
+		}
+	}
+
+	return Route{
+		Hops:     hops,
+		Amount:   MilliSatoshi(r.TotalAmtMsat - r.TotalFeesMsat),
+		Fee:      MilliSatoshi(r.TotalFeesMsat),
+		TimeLock: r.TotalTimeLock,
+	}
+}
+
+// rpcRoute for lnd from a route.
+func rpcRoute(r Route) *lnrpc.Route {
+	hops := make([]*lnrpc.Hop, len(r.Hops))
+	for i, h := range r.Hops {
+		hops[i] = &lnrpc.Hop{
+			ChanId:           uint64(h.ChannelID),
+			AmtToForwardMsat: int64(h.Forward),
+			FeeMsat:          int64(h.Fee),
+			Expiry:           h.Expiry,
+			PubKey:           string(h.PubKey),
+			TlvPayload:       true,
+		}
+	}
+
+	return &lnrpc.Route{
+		TotalTimeLock: r.TimeLock,
+		Hops:          hops,
+		TotalAmtMsat:  int64(r.Amount + r.Fee),
+		TotalFeesMsat: int64(r.Fee),
+	}
 }
 
 // SubscribeChannelUpdates signals when a channel's liquidity changes.
@@ -404,6 +905,255 @@ func (l LndClient) ForwardingHistory(ctx context.Context, since time.Time) ([]Fo
 	return forwards, nil
 }
 
+// GetNode with pubkey.
+func (l LndClient) GetNode(ctx context.Context, pubKey PubKey) (Node, error) {
+	v, err := route.NewVertexFromStr(string(pubKey))
+	if err != nil {
+		return Node{}, err
+	}
+
+	n, err := l.c.GetNodeInfo(ctx, v, false)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return Node{
+		PubKey:    PubKey(n.PubKey.String()),
+		Alias:     n.Alias,
+		Updated:   n.LastUpdate,
+		Addresses: n.Addresses,
+	}, nil
+}
+
+// ConnectPeer at address, already being connected is not an error.
+func (l LndClient) ConnectPeer(ctx context.Context, pubKey PubKey, address string) error {
+	v, err := route.NewVertexFromStr(string(pubKey))
+	if err != nil {
+		return err
+	}
+
+	err = l.c.Connect(ctx, v, address, false)
+	if err != nil && strings.Contains(err.Error(), "already connected") {
+		return nil
+	}
+
+	return err
+}
+
+// withSatPerVByte sets the fee rate of the funding transaction, zero lets lnd estimate it.
+func withSatPerVByte(feeRate SatPerVByte) lndclient.OpenChannelOption {
+	return func(r *lnrpc.OpenChannelRequest) {
+		r.SatPerVbyte = uint64(feeRate)
+	}
+}
+
+// OpenChannel funded by the local wallet, returns the funding transaction ID.
+func (l LndClient) OpenChannel(ctx context.Context, channel NewChannel, feeRate SatPerVByte) (string, error) {
+	v, err := route.NewVertexFromStr(string(channel.PubKey))
+	if err != nil {
+		return "", err
+	}
+
+	op, err := l.c.OpenChannel(ctx, v, btcutil.Amount(channel.Amount), 0, false, withSatPerVByte(feeRate))
+	if err != nil {
+		return "", err
+	}
+
+	return op.Hash.String(), nil
+}
+
+// pendingOpen is a channel open waiting on a PSBT to fund it.
+type pendingOpen struct {
+	id      [32]byte
+	updates <-chan *lndclient.OpenStatusUpdate
+	errors  <-chan error
+}
+
+// BatchOpenChannel funds all the channels with a single PSBT from the local wallet, returns the funding transaction ID.
+//
+// Every channel is negotiated with its peer first and only the final channel is allowed to publish the transaction,
+// so if any peer rejects the channel nothing is published.
+func (l LndClient) BatchOpenChannel(ctx context.Context, channels []NewChannel, feeRate SatPerVByte) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New("no channels to open")
+	}
+
+	// stop listening to the open streams on the way out
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pending := make([]pendingOpen, 0, len(channels))
+	outputs := make(map[string]uint64, len(channels))
+	for i, c := range channels {
+		v, err := route.NewVertexFromStr(string(c.PubKey))
+		if err != nil {
+			l.cancelOpens(ctx, pending)
+			return "", err
+		}
+
+		var p pendingOpen
+		if _, err := rand.Read(p.id[:]); err != nil {
+			l.cancelOpens(ctx, pending)
+			return "", err
+		}
+
+		shim := &lnrpc.FundingShim{
+			Shim: &lnrpc.FundingShim_PsbtShim{
+				PsbtShim: &lnrpc.PsbtShim{
+					PendingChanId: p.id[:],
+					// only the last channel publishes the transaction
+					NoPublish: i < len(channels)-1,
+				},
+			},
+		}
+
+		p.updates, p.errors, err = l.c.OpenChannelStream(ctx, v, btcutil.Amount(c.Amount), 0, false, lndclient.WithFundingShim(shim))
+		if err != nil {
+			l.cancelOpens(ctx, pending)
+			return "", fmt.Errorf("unable to open channel to %s: %w", c.PubKey, err)
+		}
+		pending = append(pending, p)
+
+		// wait for the peer to accept and lnd to ask for funding
+		select {
+		case u, ok := <-p.updates:
+			if !ok {
+				l.cancelOpens(ctx, pending)
+				return "", fmt.Errorf("open channel stream to %s closed before funding", c.PubKey)
+			}
+			if u.PsbtFund == nil {
+				l.cancelOpens(ctx, pending)
+				return "", fmt.Errorf("unexpected open channel update from %s", c.PubKey)
+			}
+			outputs[u.PsbtFund.FundingAddress] = uint64(u.PsbtFund.FundingAmount)
+		case err := <-p.errors:
+			l.cancelOpens(ctx, pending)
+			return "", fmt.Errorf("unable to open channel to %s: %w", c.PubKey, err)
+		}
+	}
+
+	fees := &walletrpc.FundPsbtRequest_TargetConf{TargetConf: 6}
+	req := &walletrpc.FundPsbtRequest{
+		Template: &walletrpc.FundPsbtRequest_Raw{
+			Raw: &walletrpc.TxTemplate{
+				Outputs: outputs,
+			},
+		},
+		Fees: fees,
+	}
+	if feeRate != 0 {
+		req.Fees = &walletrpc.FundPsbtRequest_SatPerVbyte{SatPerVbyte: uint64(feeRate)}
+	}
+
+	funded, _, leases, err := l.w.FundPsbt(ctx, req)
+	if err != nil {
+		l.cancelOpens(ctx, pending)
+		return "", fmt.Errorf("unable to fund channels: %w", err)
+	}
+
+	var fundedPsbt bytes.Buffer
+	if err := funded.Serialize(&fundedPsbt); err != nil {
+		l.releaseLeases(ctx, leases)
+		l.cancelOpens(ctx, pending)
+		return "", err
+	}
+
+	for _, p := range pending {
+		_, err := l.c.FundingStateStep(ctx, &lnrpc.FundingTransitionMsg{
+			Trigger: &lnrpc.FundingTransitionMsg_PsbtVerify{
+				PsbtVerify: &lnrpc.FundingPsbtVerify{
+					FundedPsbt:    fundedPsbt.Bytes(),
+					PendingChanId: p.id[:],
+				},
+			},
+		})
+		if err != nil {
+			l.releaseLeases(ctx, leases)
+			l.cancelOpens(ctx, pending)
+			return "", fmt.Errorf("unable to verify funding transaction: %w", err)
+		}
+	}
+
+	signed, tx, err := l.w.FinalizePsbt(ctx, funded, "")
+	if err != nil {
+		l.releaseLeases(ctx, leases)
+		l.cancelOpens(ctx, pending)
+		return "", fmt.Errorf("unable to sign funding transaction: %w", err)
+	}
+
+	var signedPsbt bytes.Buffer
+	if err := signed.Serialize(&signedPsbt); err != nil {
+		l.releaseLeases(ctx, leases)
+		l.cancelOpens(ctx, pending)
+		return "", err
+	}
+
+	// the last finalize publishes the transaction
+	for _, p := range pending {
+		_, err := l.c.FundingStateStep(ctx, &lnrpc.FundingTransitionMsg{
+			Trigger: &lnrpc.FundingTransitionMsg_PsbtFinalize{
+				PsbtFinalize: &lnrpc.FundingPsbtFinalize{
+					SignedPsbt:    signedPsbt.Bytes(),
+					PendingChanId: p.id[:],
+				},
+			},
+		})
+		if err != nil {
+			l.releaseLeases(ctx, leases)
+			l.cancelOpens(ctx, pending)
+			return "", fmt.Errorf("unable to finalize funding transaction: %w", err)
+		}
+	}
+
+	// channels are pending once the transaction is published
+	for i, p := range pending {
+		for published := false; !published; {
+			select {
+			case u, ok := <-p.updates:
+				if !ok {
+					return "", fmt.Errorf("funding transaction published, but channel stream to %s closed before it was pending", channels[i].PubKey)
+				}
+				published = u.ChanPending != nil
+			case err := <-p.errors:
+				return "", fmt.Errorf("funding transaction published, but channel to %s failed: %w", channels[i].PubKey, err)
+			}
+		}
+	}
+
+	return tx.TxHash().String(), nil
+}
+
+// cancelOpens which are waiting on funding, best effort since the open may have already failed.
+func (l LndClient) cancelOpens(ctx context.Context, pending []pendingOpen) {
+	for _, p := range pending {
+		_, _ = l.c.FundingStateStep(ctx, &lnrpc.FundingTransitionMsg{
+			Trigger: &lnrpc.FundingTransitionMsg_ShimCancel{
+				ShimCancel: &lnrpc.FundingShimCancel{
+					PendingChanId: p.id[:],
+				},
+			},
+		})
+	}
+}
+
+// releaseLeases on outputs locked for funding, best effort since they expire on their own.
+func (l LndClient) releaseLeases(ctx context.Context, leases []*walletrpc.UtxoLease) {
+	for _, lease := range leases {
+		hash, err := chainhash.NewHash(lease.Outpoint.TxidBytes)
+		if err != nil {
+			continue
+		}
+
+		var id wtxmgr.LockID
+		copy(id[:], lease.Id)
+
+		_ = l.w.ReleaseOutput(ctx, id, wire.OutPoint{
+			Hash:  *hash,
+			Index: lease.Outpoint.OutputIndex,
+		})
+	}
+}
+
 func decodeChannelPoint(cp string) (*wire.OutPoint, error) {
 	split := strings.SplitN(cp, ":", 2)
 
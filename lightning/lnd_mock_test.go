@@ -5,10 +5,15 @@ package lightning
 
 import (
 	"context"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wtxmgr"
 	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/routing/route"
 	"sync"
@@ -20,12 +25,21 @@ import (
 //
 //		// make and configure a mocked channeler
 //		mockedchanneler := &channelerMock{
+//			ClosedChannelsFunc: func(ctx context.Context) ([]lndclient.ClosedChannel, error) {
+//				panic("mock out the ClosedChannels method")
+//			},
+//			ConnectFunc: func(ctx context.Context, peer route.Vertex, host string, permanent bool) error {
+//				panic("mock out the Connect method")
+//			},
 //			DescribeGraphFunc: func(ctx context.Context, includeUnannounced bool) (*lndclient.Graph, error) {
 //				panic("mock out the DescribeGraph method")
 //			},
 //			ForwardingHistoryFunc: func(ctx context.Context, req lndclient.ForwardingHistoryRequest) (*lndclient.ForwardingHistoryResponse, error) {
 //				panic("mock out the ForwardingHistory method")
 //			},
+//			FundingStateStepFunc: func(ctx context.Context, req *lnrpc.FundingTransitionMsg) (*lnrpc.FundingStateStepResp, error) {
+//				panic("mock out the FundingStateStep method")
+//			},
 //			GetChanInfoFunc: func(ctx context.Context, chanId uint64) (*lndclient.ChannelEdge, error) {
 //				panic("mock out the GetChanInfo method")
 //			},
@@ -38,6 +52,15 @@ import (
 //			ListChannelsFunc: func(ctx context.Context, activeOnly bool, publicOnly bool) ([]lndclient.ChannelInfo, error) {
 //				panic("mock out the ListChannels method")
 //			},
+//			OpenChannelFunc: func(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (*wire.OutPoint, error) {
+//				panic("mock out the OpenChannel method")
+//			},
+//			OpenChannelStreamFunc: func(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (<-chan *lndclient.OpenStatusUpdate, <-chan error, error) {
+//				panic("mock out the OpenChannelStream method")
+//			},
+//			PendingChannelsFunc: func(ctx context.Context) (*lndclient.PendingChannels, error) {
+//				panic("mock out the PendingChannels method")
+//			},
 //			UpdateChanPolicyFunc: func(ctx context.Context, req lndclient.PolicyUpdateRequest, chanPoint *wire.OutPoint) error {
 //				panic("mock out the UpdateChanPolicy method")
 //			},
@@ -48,12 +71,21 @@ import (
 //
 //	}
 type channelerMock struct {
+	// ClosedChannelsFunc mocks the ClosedChannels method.
+	ClosedChannelsFunc func(ctx context.Context) ([]lndclient.ClosedChannel, error)
+
+	// ConnectFunc mocks the Connect method.
+	ConnectFunc func(ctx context.Context, peer route.Vertex, host string, permanent bool) error
+
 	// DescribeGraphFunc mocks the DescribeGraph method.
 	DescribeGraphFunc func(ctx context.Context, includeUnannounced bool) (*lndclient.Graph, error)
 
 	// ForwardingHistoryFunc mocks the ForwardingHistory method.
 	ForwardingHistoryFunc func(ctx context.Context, req lndclient.ForwardingHistoryRequest) (*lndclient.ForwardingHistoryResponse, error)
 
+	// FundingStateStepFunc mocks the FundingStateStep method.
+	FundingStateStepFunc func(ctx context.Context, req *lnrpc.FundingTransitionMsg) (*lnrpc.FundingStateStepResp, error)
+
 	// GetChanInfoFunc mocks the GetChanInfo method.
 	GetChanInfoFunc func(ctx context.Context, chanId uint64) (*lndclient.ChannelEdge, error)
 
@@ -66,11 +98,36 @@ type channelerMock struct {
 	// ListChannelsFunc mocks the ListChannels method.
 	ListChannelsFunc func(ctx context.Context, activeOnly bool, publicOnly bool) ([]lndclient.ChannelInfo, error)
 
+	// OpenChannelFunc mocks the OpenChannel method.
+	OpenChannelFunc func(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (*wire.OutPoint, error)
+
+	// OpenChannelStreamFunc mocks the OpenChannelStream method.
+	OpenChannelStreamFunc func(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (<-chan *lndclient.OpenStatusUpdate, <-chan error, error)
+
+	// PendingChannelsFunc mocks the PendingChannels method.
+	PendingChannelsFunc func(ctx context.Context) (*lndclient.PendingChannels, error)
+
 	// UpdateChanPolicyFunc mocks the UpdateChanPolicy method.
 	UpdateChanPolicyFunc func(ctx context.Context, req lndclient.PolicyUpdateRequest, chanPoint *wire.OutPoint) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// ClosedChannels holds details about calls to the ClosedChannels method.
+		ClosedChannels []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Connect holds details about calls to the Connect method.
+		Connect []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Peer is the peer argument value.
+			Peer route.Vertex
+			// Host is the host argument value.
+			Host string
+			// Permanent is the permanent argument value.
+			Permanent bool
+		}
 		// DescribeGraph holds details about calls to the DescribeGraph method.
 		DescribeGraph []struct {
 			// Ctx is the ctx argument value.
@@ -85,6 +142,13 @@ type channelerMock struct {
 			// Req is the req argument value.
 			Req lndclient.ForwardingHistoryRequest
 		}
+		// FundingStateStep holds details about calls to the FundingStateStep method.
+		FundingStateStep []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Req is the req argument value.
+			Req *lnrpc.FundingTransitionMsg
+		}
 		// GetChanInfo holds details about calls to the GetChanInfo method.
 		GetChanInfo []struct {
 			// Ctx is the ctx argument value.
@@ -115,6 +179,41 @@ type channelerMock struct {
 			// PublicOnly is the publicOnly argument value.
 			PublicOnly bool
 		}
+		// OpenChannel holds details about calls to the OpenChannel method.
+		OpenChannel []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Peer is the peer argument value.
+			Peer route.Vertex
+			// LocalSat is the localSat argument value.
+			LocalSat btcutil.Amount
+			// PushSat is the pushSat argument value.
+			PushSat btcutil.Amount
+			// Private is the private argument value.
+			Private bool
+			// Opts is the opts argument value.
+			Opts []lndclient.OpenChannelOption
+		}
+		// OpenChannelStream holds details about calls to the OpenChannelStream method.
+		OpenChannelStream []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Peer is the peer argument value.
+			Peer route.Vertex
+			// LocalSat is the localSat argument value.
+			LocalSat btcutil.Amount
+			// PushSat is the pushSat argument value.
+			PushSat btcutil.Amount
+			// Private is the private argument value.
+			Private bool
+			// Opts is the opts argument value.
+			Opts []lndclient.OpenChannelOption
+		}
+		// PendingChannels holds details about calls to the PendingChannels method.
+		PendingChannels []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 		// UpdateChanPolicy holds details about calls to the UpdateChanPolicy method.
 		UpdateChanPolicy []struct {
 			// Ctx is the ctx argument value.
@@ -125,15 +224,104 @@ type channelerMock struct {
 			ChanPoint *wire.OutPoint
 		}
 	}
+	lockClosedChannels    sync.RWMutex
+	lockConnect           sync.RWMutex
 	lockDescribeGraph     sync.RWMutex
 	lockForwardingHistory sync.RWMutex
+	lockFundingStateStep  sync.RWMutex
 	lockGetChanInfo       sync.RWMutex
 	lockGetInfo           sync.RWMutex
 	lockGetNodeInfo       sync.RWMutex
 	lockListChannels      sync.RWMutex
+	lockOpenChannel       sync.RWMutex
+	lockOpenChannelStream sync.RWMutex
+	lockPendingChannels   sync.RWMutex
 	lockUpdateChanPolicy  sync.RWMutex
 }
 
+// ClosedChannels calls ClosedChannelsFunc.
+func (mock *channelerMock) ClosedChannels(ctx context.Context) ([]lndclient.ClosedChannel, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockClosedChannels.Lock()
+	mock.calls.ClosedChannels = append(mock.calls.ClosedChannels, callInfo)
+	mock.lockClosedChannels.Unlock()
+	if mock.ClosedChannelsFunc == nil {
+		var (
+			closedChannelsOut []lndclient.ClosedChannel
+			errOut            error
+		)
+		return closedChannelsOut, errOut
+	}
+	return mock.ClosedChannelsFunc(ctx)
+}
+
+// ClosedChannelsCalls gets all the calls that were made to ClosedChannels.
+// Check the length with:
+//
+//	len(mockedchanneler.ClosedChannelsCalls())
+func (mock *channelerMock) ClosedChannelsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockClosedChannels.RLock()
+	calls = mock.calls.ClosedChannels
+	mock.lockClosedChannels.RUnlock()
+	return calls
+}
+
+// Connect calls ConnectFunc.
+func (mock *channelerMock) Connect(ctx context.Context, peer route.Vertex, host string, permanent bool) error {
+	callInfo := struct {
+		Ctx       context.Context
+		Peer      route.Vertex
+		Host      string
+		Permanent bool
+	}{
+		Ctx:       ctx,
+		Peer:      peer,
+		Host:      host,
+		Permanent: permanent,
+	}
+	mock.lockConnect.Lock()
+	mock.calls.Connect = append(mock.calls.Connect, callInfo)
+	mock.lockConnect.Unlock()
+	if mock.ConnectFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.ConnectFunc(ctx, peer, host, permanent)
+}
+
+// ConnectCalls gets all the calls that were made to Connect.
+// Check the length with:
+//
+//	len(mockedchanneler.ConnectCalls())
+func (mock *channelerMock) ConnectCalls() []struct {
+	Ctx       context.Context
+	Peer      route.Vertex
+	Host      string
+	Permanent bool
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Peer      route.Vertex
+		Host      string
+		Permanent bool
+	}
+	mock.lockConnect.RLock()
+	calls = mock.calls.Connect
+	mock.lockConnect.RUnlock()
+	return calls
+}
+
 // DescribeGraph calls DescribeGraphFunc.
 func (mock *channelerMock) DescribeGraph(ctx context.Context, includeUnannounced bool) (*lndclient.Graph, error) {
 	callInfo := struct {
@@ -214,6 +402,46 @@ func (mock *channelerMock) ForwardingHistoryCalls() []struct {
 	return calls
 }
 
+// FundingStateStep calls FundingStateStepFunc.
+func (mock *channelerMock) FundingStateStep(ctx context.Context, req *lnrpc.FundingTransitionMsg) (*lnrpc.FundingStateStepResp, error) {
+	callInfo := struct {
+		Ctx context.Context
+		Req *lnrpc.FundingTransitionMsg
+	}{
+		Ctx: ctx,
+		Req: req,
+	}
+	mock.lockFundingStateStep.Lock()
+	mock.calls.FundingStateStep = append(mock.calls.FundingStateStep, callInfo)
+	mock.lockFundingStateStep.Unlock()
+	if mock.FundingStateStepFunc == nil {
+		var (
+			fundingStateStepRespOut *lnrpc.FundingStateStepResp
+			errOut                  error
+		)
+		return fundingStateStepRespOut, errOut
+	}
+	return mock.FundingStateStepFunc(ctx, req)
+}
+
+// FundingStateStepCalls gets all the calls that were made to FundingStateStep.
+// Check the length with:
+//
+//	len(mockedchanneler.FundingStateStepCalls())
+func (mock *channelerMock) FundingStateStepCalls() []struct {
+	Ctx context.Context
+	Req *lnrpc.FundingTransitionMsg
+} {
+	var calls []struct {
+		Ctx context.Context
+		Req *lnrpc.FundingTransitionMsg
+	}
+	mock.lockFundingStateStep.RLock()
+	calls = mock.calls.FundingStateStep
+	mock.lockFundingStateStep.RUnlock()
+	return calls
+}
+
 // GetChanInfo calls GetChanInfoFunc.
 func (mock *channelerMock) GetChanInfo(ctx context.Context, chanId uint64) (*lndclient.ChannelEdge, error) {
 	callInfo := struct {
@@ -378,6 +606,155 @@ func (mock *channelerMock) ListChannelsCalls() []struct {
 	return calls
 }
 
+// OpenChannel calls OpenChannelFunc.
+func (mock *channelerMock) OpenChannel(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (*wire.OutPoint, error) {
+	callInfo := struct {
+		Ctx      context.Context
+		Peer     route.Vertex
+		LocalSat btcutil.Amount
+		PushSat  btcutil.Amount
+		Private  bool
+		Opts     []lndclient.OpenChannelOption
+	}{
+		Ctx:      ctx,
+		Peer:     peer,
+		LocalSat: localSat,
+		PushSat:  pushSat,
+		Private:  private,
+		Opts:     opts,
+	}
+	mock.lockOpenChannel.Lock()
+	mock.calls.OpenChannel = append(mock.calls.OpenChannel, callInfo)
+	mock.lockOpenChannel.Unlock()
+	if mock.OpenChannelFunc == nil {
+		var (
+			outPointOut *wire.OutPoint
+			errOut      error
+		)
+		return outPointOut, errOut
+	}
+	return mock.OpenChannelFunc(ctx, peer, localSat, pushSat, private, opts...)
+}
+
+// OpenChannelCalls gets all the calls that were made to OpenChannel.
+// Check the length with:
+//
+//	len(mockedchanneler.OpenChannelCalls())
+func (mock *channelerMock) OpenChannelCalls() []struct {
+	Ctx      context.Context
+	Peer     route.Vertex
+	LocalSat btcutil.Amount
+	PushSat  btcutil.Amount
+	Private  bool
+	Opts     []lndclient.OpenChannelOption
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Peer     route.Vertex
+		LocalSat btcutil.Amount
+		PushSat  btcutil.Amount
+		Private  bool
+		Opts     []lndclient.OpenChannelOption
+	}
+	mock.lockOpenChannel.RLock()
+	calls = mock.calls.OpenChannel
+	mock.lockOpenChannel.RUnlock()
+	return calls
+}
+
+// OpenChannelStream calls OpenChannelStreamFunc.
+func (mock *channelerMock) OpenChannelStream(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (<-chan *lndclient.OpenStatusUpdate, <-chan error, error) {
+	callInfo := struct {
+		Ctx      context.Context
+		Peer     route.Vertex
+		LocalSat btcutil.Amount
+		PushSat  btcutil.Amount
+		Private  bool
+		Opts     []lndclient.OpenChannelOption
+	}{
+		Ctx:      ctx,
+		Peer:     peer,
+		LocalSat: localSat,
+		PushSat:  pushSat,
+		Private:  private,
+		Opts:     opts,
+	}
+	mock.lockOpenChannelStream.Lock()
+	mock.calls.OpenChannelStream = append(mock.calls.OpenChannelStream, callInfo)
+	mock.lockOpenChannelStream.Unlock()
+	if mock.OpenChannelStreamFunc == nil {
+		var (
+			openStatusUpdateChOut <-chan *lndclient.OpenStatusUpdate
+			errChOut              <-chan error
+			errOut                error
+		)
+		return openStatusUpdateChOut, errChOut, errOut
+	}
+	return mock.OpenChannelStreamFunc(ctx, peer, localSat, pushSat, private, opts...)
+}
+
+// OpenChannelStreamCalls gets all the calls that were made to OpenChannelStream.
+// Check the length with:
+//
+//	len(mockedchanneler.OpenChannelStreamCalls())
+func (mock *channelerMock) OpenChannelStreamCalls() []struct {
+	Ctx      context.Context
+	Peer     route.Vertex
+	LocalSat btcutil.Amount
+	PushSat  btcutil.Amount
+	Private  bool
+	Opts     []lndclient.OpenChannelOption
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Peer     route.Vertex
+		LocalSat btcutil.Amount
+		PushSat  btcutil.Amount
+		Private  bool
+		Opts     []lndclient.OpenChannelOption
+	}
+	mock.lockOpenChannelStream.RLock()
+	calls = mock.calls.OpenChannelStream
+	mock.lockOpenChannelStream.RUnlock()
+	return calls
+}
+
+// PendingChannels calls PendingChannelsFunc.
+func (mock *channelerMock) PendingChannels(ctx context.Context) (*lndclient.PendingChannels, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockPendingChannels.Lock()
+	mock.calls.PendingChannels = append(mock.calls.PendingChannels, callInfo)
+	mock.lockPendingChannels.Unlock()
+	if mock.PendingChannelsFunc == nil {
+		var (
+			pendingChannelsOut *lndclient.PendingChannels
+			errOut             error
+		)
+		return pendingChannelsOut, errOut
+	}
+	return mock.PendingChannelsFunc(ctx)
+}
+
+// PendingChannelsCalls gets all the calls that were made to PendingChannels.
+// Check the length with:
+//
+//	len(mockedchanneler.PendingChannelsCalls())
+func (mock *channelerMock) PendingChannelsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockPendingChannels.RLock()
+	calls = mock.calls.PendingChannels
+	mock.lockPendingChannels.RUnlock()
+	return calls
+}
+
 // UpdateChanPolicy calls UpdateChanPolicyFunc.
 func (mock *channelerMock) UpdateChanPolicy(ctx context.Context, req lndclient.PolicyUpdateRequest, chanPoint *wire.OutPoint) error {
 	callInfo := struct {
@@ -552,6 +929,12 @@ func (mock *routerMock) SubscribeHtlcEventsCalls() []struct {
 //			AddInvoiceFunc: func(ctx context.Context, in *invoicesrpc.AddInvoiceData) (lntypes.Hash, string, error) {
 //				panic("mock out the AddInvoice method")
 //			},
+//			CancelInvoiceFunc: func(ctx context.Context, hash lntypes.Hash) error {
+//				panic("mock out the CancelInvoice method")
+//			},
+//			ListInvoicesFunc: func(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error) {
+//				panic("mock out the ListInvoices method")
+//			},
 //		}
 //
 //		// use mockedinvoicer in code that requires invoicer
@@ -562,6 +945,12 @@ type invoicerMock struct {
 	// AddInvoiceFunc mocks the AddInvoice method.
 	AddInvoiceFunc func(ctx context.Context, in *invoicesrpc.AddInvoiceData) (lntypes.Hash, string, error)
 
+	// CancelInvoiceFunc mocks the CancelInvoice method.
+	CancelInvoiceFunc func(ctx context.Context, hash lntypes.Hash) error
+
+	// ListInvoicesFunc mocks the ListInvoices method.
+	ListInvoicesFunc func(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// AddInvoice holds details about calls to the AddInvoice method.
@@ -571,8 +960,24 @@ type invoicerMock struct {
 			// In is the in argument value.
 			In *invoicesrpc.AddInvoiceData
 		}
+		// CancelInvoice holds details about calls to the CancelInvoice method.
+		CancelInvoice []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Hash is the hash argument value.
+			Hash lntypes.Hash
+		}
+		// ListInvoices holds details about calls to the ListInvoices method.
+		ListInvoices []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Req is the req argument value.
+			Req lndclient.ListInvoicesRequest
+		}
 	}
-	lockAddInvoice sync.RWMutex
+	lockAddInvoice    sync.RWMutex
+	lockCancelInvoice sync.RWMutex
+	lockListInvoices  sync.RWMutex
 }
 
 // AddInvoice calls AddInvoiceFunc.
@@ -615,3 +1020,510 @@ func (mock *invoicerMock) AddInvoiceCalls() []struct {
 	mock.lockAddInvoice.RUnlock()
 	return calls
 }
+
+// CancelInvoice calls CancelInvoiceFunc.
+func (mock *invoicerMock) CancelInvoice(ctx context.Context, hash lntypes.Hash) error {
+	callInfo := struct {
+		Ctx  context.Context
+		Hash lntypes.Hash
+	}{
+		Ctx:  ctx,
+		Hash: hash,
+	}
+	mock.lockCancelInvoice.Lock()
+	mock.calls.CancelInvoice = append(mock.calls.CancelInvoice, callInfo)
+	mock.lockCancelInvoice.Unlock()
+	if mock.CancelInvoiceFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CancelInvoiceFunc(ctx, hash)
+}
+
+// CancelInvoiceCalls gets all the calls that were made to CancelInvoice.
+// Check the length with:
+//
+//	len(mockedinvoicer.CancelInvoiceCalls())
+func (mock *invoicerMock) CancelInvoiceCalls() []struct {
+	Ctx  context.Context
+	Hash lntypes.Hash
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Hash lntypes.Hash
+	}
+	mock.lockCancelInvoice.RLock()
+	calls = mock.calls.CancelInvoice
+	mock.lockCancelInvoice.RUnlock()
+	return calls
+}
+
+// ListInvoices calls ListInvoicesFunc.
+func (mock *invoicerMock) ListInvoices(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error) {
+	callInfo := struct {
+		Ctx context.Context
+		Req lndclient.ListInvoicesRequest
+	}{
+		Ctx: ctx,
+		Req: req,
+	}
+	mock.lockListInvoices.Lock()
+	mock.calls.ListInvoices = append(mock.calls.ListInvoices, callInfo)
+	mock.lockListInvoices.Unlock()
+	if mock.ListInvoicesFunc == nil {
+		var (
+			listInvoicesResponseOut *lndclient.ListInvoicesResponse
+			errOut                  error
+		)
+		return listInvoicesResponseOut, errOut
+	}
+	return mock.ListInvoicesFunc(ctx, req)
+}
+
+// ListInvoicesCalls gets all the calls that were made to ListInvoices.
+// Check the length with:
+//
+//	len(mockedinvoicer.ListInvoicesCalls())
+func (mock *invoicerMock) ListInvoicesCalls() []struct {
+	Ctx context.Context
+	Req lndclient.ListInvoicesRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		Req lndclient.ListInvoicesRequest
+	}
+	mock.lockListInvoices.RLock()
+	calls = mock.calls.ListInvoices
+	mock.lockListInvoices.RUnlock()
+	return calls
+}
+
+// walleterMock is a mock implementation of walleter.
+//
+//	func TestSomethingThatUseswalleter(t *testing.T) {
+//
+//		// make and configure a mocked walleter
+//		mockedwalleter := &walleterMock{
+//			FinalizePsbtFunc: func(ctx context.Context, packet *psbt.Packet, account string) (*psbt.Packet, *wire.MsgTx, error) {
+//				panic("mock out the FinalizePsbt method")
+//			},
+//			FundPsbtFunc: func(ctx context.Context, req *walletrpc.FundPsbtRequest) (*psbt.Packet, int32, []*walletrpc.UtxoLease, error) {
+//				panic("mock out the FundPsbt method")
+//			},
+//			ReleaseOutputFunc: func(ctx context.Context, lockID wtxmgr.LockID, op wire.OutPoint) error {
+//				panic("mock out the ReleaseOutput method")
+//			},
+//		}
+//
+//		// use mockedwalleter in code that requires walleter
+//		// and then make assertions.
+//
+//	}
+type walleterMock struct {
+	// FinalizePsbtFunc mocks the FinalizePsbt method.
+	FinalizePsbtFunc func(ctx context.Context, packet *psbt.Packet, account string) (*psbt.Packet, *wire.MsgTx, error)
+
+	// FundPsbtFunc mocks the FundPsbt method.
+	FundPsbtFunc func(ctx context.Context, req *walletrpc.FundPsbtRequest) (*psbt.Packet, int32, []*walletrpc.UtxoLease, error)
+
+	// ReleaseOutputFunc mocks the ReleaseOutput method.
+	ReleaseOutputFunc func(ctx context.Context, lockID wtxmgr.LockID, op wire.OutPoint) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// FinalizePsbt holds details about calls to the FinalizePsbt method.
+		FinalizePsbt []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Packet is the packet argument value.
+			Packet *psbt.Packet
+			// Account is the account argument value.
+			Account string
+		}
+		// FundPsbt holds details about calls to the FundPsbt method.
+		FundPsbt []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Req is the req argument value.
+			Req *walletrpc.FundPsbtRequest
+		}
+		// ReleaseOutput holds details about calls to the ReleaseOutput method.
+		ReleaseOutput []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// LockID is the lockID argument value.
+			LockID wtxmgr.LockID
+			// Op is the op argument value.
+			Op wire.OutPoint
+		}
+	}
+	lockFinalizePsbt  sync.RWMutex
+	lockFundPsbt      sync.RWMutex
+	lockReleaseOutput sync.RWMutex
+}
+
+// FinalizePsbt calls FinalizePsbtFunc.
+func (mock *walleterMock) FinalizePsbt(ctx context.Context, packet *psbt.Packet, account string) (*psbt.Packet, *wire.MsgTx, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Packet  *psbt.Packet
+		Account string
+	}{
+		Ctx:     ctx,
+		Packet:  packet,
+		Account: account,
+	}
+	mock.lockFinalizePsbt.Lock()
+	mock.calls.FinalizePsbt = append(mock.calls.FinalizePsbt, callInfo)
+	mock.lockFinalizePsbt.Unlock()
+	if mock.FinalizePsbtFunc == nil {
+		var (
+			packetOut *psbt.Packet
+			msgTxOut  *wire.MsgTx
+			errOut    error
+		)
+		return packetOut, msgTxOut, errOut
+	}
+	return mock.FinalizePsbtFunc(ctx, packet, account)
+}
+
+// FinalizePsbtCalls gets all the calls that were made to FinalizePsbt.
+// Check the length with:
+//
+//	len(mockedwalleter.FinalizePsbtCalls())
+func (mock *walleterMock) FinalizePsbtCalls() []struct {
+	Ctx     context.Context
+	Packet  *psbt.Packet
+	Account string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Packet  *psbt.Packet
+		Account string
+	}
+	mock.lockFinalizePsbt.RLock()
+	calls = mock.calls.FinalizePsbt
+	mock.lockFinalizePsbt.RUnlock()
+	return calls
+}
+
+// FundPsbt calls FundPsbtFunc.
+func (mock *walleterMock) FundPsbt(ctx context.Context, req *walletrpc.FundPsbtRequest) (*psbt.Packet, int32, []*walletrpc.UtxoLease, error) {
+	callInfo := struct {
+		Ctx context.Context
+		Req *walletrpc.FundPsbtRequest
+	}{
+		Ctx: ctx,
+		Req: req,
+	}
+	mock.lockFundPsbt.Lock()
+	mock.calls.FundPsbt = append(mock.calls.FundPsbt, callInfo)
+	mock.lockFundPsbt.Unlock()
+	if mock.FundPsbtFunc == nil {
+		var (
+			packetOut     *psbt.Packet
+			nOut          int32
+			utxoLeasesOut []*walletrpc.UtxoLease
+			errOut        error
+		)
+		return packetOut, nOut, utxoLeasesOut, errOut
+	}
+	return mock.FundPsbtFunc(ctx, req)
+}
+
+// FundPsbtCalls gets all the calls that were made to FundPsbt.
+// Check the length with:
+//
+//	len(mockedwalleter.FundPsbtCalls())
+func (mock *walleterMock) FundPsbtCalls() []struct {
+	Ctx context.Context
+	Req *walletrpc.FundPsbtRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		Req *walletrpc.FundPsbtRequest
+	}
+	mock.lockFundPsbt.RLock()
+	calls = mock.calls.FundPsbt
+	mock.lockFundPsbt.RUnlock()
+	return calls
+}
+
+// ReleaseOutput calls ReleaseOutputFunc.
+func (mock *walleterMock) ReleaseOutput(ctx context.Context, lockID wtxmgr.LockID, op wire.OutPoint) error {
+	callInfo := struct {
+		Ctx    context.Context
+		LockID wtxmgr.LockID
+		Op     wire.OutPoint
+	}{
+		Ctx:    ctx,
+		LockID: lockID,
+		Op:     op,
+	}
+	mock.lockReleaseOutput.Lock()
+	mock.calls.ReleaseOutput = append(mock.calls.ReleaseOutput, callInfo)
+	mock.lockReleaseOutput.Unlock()
+	if mock.ReleaseOutputFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.ReleaseOutputFunc(ctx, lockID, op)
+}
+
+// ReleaseOutputCalls gets all the calls that were made to ReleaseOutput.
+// Check the length with:
+//
+//	len(mockedwalleter.ReleaseOutputCalls())
+func (mock *walleterMock) ReleaseOutputCalls() []struct {
+	Ctx    context.Context
+	LockID wtxmgr.LockID
+	Op     wire.OutPoint
+} {
+	var calls []struct {
+		Ctx    context.Context
+		LockID wtxmgr.LockID
+		Op     wire.OutPoint
+	}
+	mock.lockReleaseOutput.RLock()
+	calls = mock.calls.ReleaseOutput
+	mock.lockReleaseOutput.RUnlock()
+	return calls
+}
+
+// patherMock is a mock implementation of pather.
+//
+//	func TestSomethingThatUsespather(t *testing.T) {
+//
+//		// make and configure a mocked pather
+//		mockedpather := &patherMock{
+//			BuildRouteFunc: func(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error) {
+//				panic("mock out the BuildRoute method")
+//			},
+//			DeletePaymentFunc: func(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error) {
+//				panic("mock out the DeletePayment method")
+//			},
+//			QueryRoutesFunc: func(ctx context.Context, in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error) {
+//				panic("mock out the QueryRoutes method")
+//			},
+//			SendToRouteV2Func: func(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error) {
+//				panic("mock out the SendToRouteV2 method")
+//			},
+//		}
+//
+//		// use mockedpather in code that requires pather
+//		// and then make assertions.
+//
+//	}
+type patherMock struct {
+	// BuildRouteFunc mocks the BuildRoute method.
+	BuildRouteFunc func(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error)
+
+	// DeletePaymentFunc mocks the DeletePayment method.
+	DeletePaymentFunc func(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error)
+
+	// QueryRoutesFunc mocks the QueryRoutes method.
+	QueryRoutesFunc func(ctx context.Context, in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error)
+
+	// SendToRouteV2Func mocks the SendToRouteV2 method.
+	SendToRouteV2Func func(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// BuildRoute holds details about calls to the BuildRoute method.
+		BuildRoute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *routerrpc.BuildRouteRequest
+		}
+		// DeletePayment holds details about calls to the DeletePayment method.
+		DeletePayment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *lnrpc.DeletePaymentRequest
+		}
+		// QueryRoutes holds details about calls to the QueryRoutes method.
+		QueryRoutes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *lnrpc.QueryRoutesRequest
+		}
+		// SendToRouteV2 holds details about calls to the SendToRouteV2 method.
+		SendToRouteV2 []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In *routerrpc.SendToRouteRequest
+		}
+	}
+	lockBuildRoute    sync.RWMutex
+	lockDeletePayment sync.RWMutex
+	lockQueryRoutes   sync.RWMutex
+	lockSendToRouteV2 sync.RWMutex
+}
+
+// BuildRoute calls BuildRouteFunc.
+func (mock *patherMock) BuildRoute(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  *routerrpc.BuildRouteRequest
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockBuildRoute.Lock()
+	mock.calls.BuildRoute = append(mock.calls.BuildRoute, callInfo)
+	mock.lockBuildRoute.Unlock()
+	if mock.BuildRouteFunc == nil {
+		var (
+			buildRouteResponseOut *routerrpc.BuildRouteResponse
+			errOut                error
+		)
+		return buildRouteResponseOut, errOut
+	}
+	return mock.BuildRouteFunc(ctx, in)
+}
+
+// BuildRouteCalls gets all the calls that were made to BuildRoute.
+// Check the length with:
+//
+//	len(mockedpather.BuildRouteCalls())
+func (mock *patherMock) BuildRouteCalls() []struct {
+	Ctx context.Context
+	In  *routerrpc.BuildRouteRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  *routerrpc.BuildRouteRequest
+	}
+	mock.lockBuildRoute.RLock()
+	calls = mock.calls.BuildRoute
+	mock.lockBuildRoute.RUnlock()
+	return calls
+}
+
+// DeletePayment calls DeletePaymentFunc.
+func (mock *patherMock) DeletePayment(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  *lnrpc.DeletePaymentRequest
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockDeletePayment.Lock()
+	mock.calls.DeletePayment = append(mock.calls.DeletePayment, callInfo)
+	mock.lockDeletePayment.Unlock()
+	if mock.DeletePaymentFunc == nil {
+		var (
+			deletePaymentResponseOut *lnrpc.DeletePaymentResponse
+			errOut                   error
+		)
+		return deletePaymentResponseOut, errOut
+	}
+	return mock.DeletePaymentFunc(ctx, in)
+}
+
+// DeletePaymentCalls gets all the calls that were made to DeletePayment.
+// Check the length with:
+//
+//	len(mockedpather.DeletePaymentCalls())
+func (mock *patherMock) DeletePaymentCalls() []struct {
+	Ctx context.Context
+	In  *lnrpc.DeletePaymentRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  *lnrpc.DeletePaymentRequest
+	}
+	mock.lockDeletePayment.RLock()
+	calls = mock.calls.DeletePayment
+	mock.lockDeletePayment.RUnlock()
+	return calls
+}
+
+// QueryRoutes calls QueryRoutesFunc.
+func (mock *patherMock) QueryRoutes(ctx context.Context, in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  *lnrpc.QueryRoutesRequest
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockQueryRoutes.Lock()
+	mock.calls.QueryRoutes = append(mock.calls.QueryRoutes, callInfo)
+	mock.lockQueryRoutes.Unlock()
+	if mock.QueryRoutesFunc == nil {
+		var (
+			queryRoutesResponseOut *lnrpc.QueryRoutesResponse
+			errOut                 error
+		)
+		return queryRoutesResponseOut, errOut
+	}
+	return mock.QueryRoutesFunc(ctx, in)
+}
+
+// QueryRoutesCalls gets all the calls that were made to QueryRoutes.
+// Check the length with:
+//
+//	len(mockedpather.QueryRoutesCalls())
+func (mock *patherMock) QueryRoutesCalls() []struct {
+	Ctx context.Context
+	In  *lnrpc.QueryRoutesRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  *lnrpc.QueryRoutesRequest
+	}
+	mock.lockQueryRoutes.RLock()
+	calls = mock.calls.QueryRoutes
+	mock.lockQueryRoutes.RUnlock()
+	return calls
+}
+
+// SendToRouteV2 calls SendToRouteV2Func.
+func (mock *patherMock) SendToRouteV2(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  *routerrpc.SendToRouteRequest
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockSendToRouteV2.Lock()
+	mock.calls.SendToRouteV2 = append(mock.calls.SendToRouteV2, callInfo)
+	mock.lockSendToRouteV2.Unlock()
+	if mock.SendToRouteV2Func == nil {
+		var (
+			hTLCAttemptOut *lnrpc.HTLCAttempt
+			errOut         error
+		)
+		return hTLCAttemptOut, errOut
+	}
+	return mock.SendToRouteV2Func(ctx, in)
+}
+
+// SendToRouteV2Calls gets all the calls that were made to SendToRouteV2.
+// Check the length with:
+//
+//	len(mockedpather.SendToRouteV2Calls())
+func (mock *patherMock) SendToRouteV2Calls() []struct {
+	Ctx context.Context
+	In  *routerrpc.SendToRouteRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  *routerrpc.SendToRouteRequest
+	}
+	mock.lockSendToRouteV2.RLock()
+	calls = mock.calls.SendToRouteV2
+	mock.lockSendToRouteV2.RUnlock()
+	return calls
+}
@@ -1,13 +1,30 @@
 package lightning
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/lightninglabs/lndclient"
+	invpkg "github.com/lightningnetwork/lnd/invoices"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/zpay32"
 )
 
 func TestLndClient_GetInfo(t *testing.T) {
@@ -204,3 +221,719 @@ func TestLndClient_ListChannels(t *testing.T) {
 		})
 	}
 }
+
+func TestLndClient_DescribeGraph(t *testing.T) {
+	var pubKey1 [33]byte = [33]byte{1}
+	var pubKey2 [33]byte = [33]byte{2}
+
+	type fields struct {
+		c channeler
+		r router
+		i invoicer
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *Graph
+		wantErr bool
+	}{
+		{
+			name: "missing policies are nil",
+			fields: fields{
+				c: &channelerMock{
+					DescribeGraphFunc: func(ctx context.Context, includeUnannounced bool) (*lndclient.Graph, error) {
+						return &lndclient.Graph{
+							Nodes: []lndclient.Node{
+								{
+									PubKey:     pubKey1,
+									Alias:      "1",
+									Addresses:  []string{"address"},
+									LastUpdate: time.Time{},
+								},
+							},
+							Edges: []lndclient.ChannelEdge{
+								{
+									ChannelID: 800000<<40 | 1<<16,
+									Capacity:  1000,
+									Node1:     pubKey1,
+									Node2:     pubKey2,
+									Node1Policy: &lndclient.RoutingPolicy{
+										FeeRateMilliMsat: 100,
+										FeeBaseMsat:      1000,
+										Disabled:         true,
+									},
+								},
+							},
+						}, nil
+					},
+					GetInfoFunc: func(ctx context.Context) (*lndclient.Info, error) {
+						return &lndclient.Info{
+							BlockHeight: 850000,
+						}, nil
+					},
+				},
+			},
+			want: &Graph{
+				Nodes: []Node{
+					{
+						PubKey:    PubKey(route.Vertex(pubKey1).String()),
+						Alias:     "1",
+						Updated:   time.Time{},
+						Addresses: []string{"address"},
+					},
+				},
+				Edges: []Edge{
+					{
+						Capacity: 1000,
+						Node1:    PubKey(route.Vertex(pubKey1).String()),
+						Node2:    PubKey(route.Vertex(pubKey2).String()),
+						Node1Policy: &RoutingPolicy{
+							FeeRate:  100,
+							BaseFee:  1000,
+							Disabled: true,
+						},
+						Node2Policy:   nil,
+						FundingHeight: 800000,
+					},
+				},
+				Height: 850000,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := LndClient{
+				c: tt.fields.c,
+				r: tt.fields.r,
+				i: tt.fields.i,
+			}
+			got, err := l.DescribeGraph(tt.args.ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LndClient.DescribeGraph() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LndClient.DescribeGraph() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLndClient_ConnectPeer(t *testing.T) {
+	pubKey := PubKey(route.Vertex([33]byte{1}).String())
+
+	type fields struct {
+		c channeler
+	}
+	type args struct {
+		ctx     context.Context
+		pubKey  PubKey
+		address string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "already connected is not an error",
+			fields: fields{
+				c: &channelerMock{
+					ConnectFunc: func(ctx context.Context, peer route.Vertex, host string, permanent bool) error {
+						return errors.New("already connected to peer")
+					},
+				},
+			},
+			args: args{
+				ctx:     context.Background(),
+				pubKey:  pubKey,
+				address: "address",
+			},
+			wantErr: false,
+		},
+		{
+			name: "connection failures are errors",
+			fields: fields{
+				c: &channelerMock{
+					ConnectFunc: func(ctx context.Context, peer route.Vertex, host string, permanent bool) error {
+						return errors.New("connection refused")
+					},
+				},
+			},
+			args: args{
+				ctx:     context.Background(),
+				pubKey:  pubKey,
+				address: "address",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid pubkey",
+			fields: fields{
+				c: &channelerMock{},
+			},
+			args: args{
+				ctx:     context.Background(),
+				pubKey:  "A",
+				address: "address",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := LndClient{
+				c: tt.fields.c,
+			}
+			if err := l.ConnectPeer(tt.args.ctx, tt.args.pubKey, tt.args.address); (err != nil) != tt.wantErr {
+				t.Errorf("LndClient.ConnectPeer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_payment(t *testing.T) {
+	route := &lnrpc.Route{
+		Hops: []*lnrpc.Hop{
+			{ChanId: 1, PubKey: "B"},
+			{ChanId: 2, PubKey: "C"},
+			{ChanId: 3, PubKey: "A"},
+		},
+	}
+	hops := []Hop{
+		{ChannelID: 1, PubKey: "B"},
+		{ChannelID: 2, PubKey: "C"},
+		{ChannelID: 3, PubKey: "A"},
+	}
+
+	tests := []struct {
+		name   string
+		status lndclient.PaymentStatus
+		want   Payment
+	}{
+		{
+			name: "settled attempt route",
+			status: lndclient.PaymentStatus{
+				State: lnrpc.Payment_SUCCEEDED,
+				Fee:   2000,
+				Htlcs: []*lndclient.HtlcAttempt{
+					{Status: lnrpc.HTLCAttempt_FAILED, Failure: &lndclient.HtlcFailure{Code: lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE, FailureSourceIndex: 1}},
+					{Status: lnrpc.HTLCAttempt_SUCCEEDED, Route: route},
+				},
+			},
+			want: Payment{
+				Fee:   2,
+				Route: hops,
+			},
+		},
+		{
+			name: "last failed attempt",
+			status: lndclient.PaymentStatus{
+				State: lnrpc.Payment_FAILED,
+				Htlcs: []*lndclient.HtlcAttempt{
+					{Status: lnrpc.HTLCAttempt_FAILED, Route: route, Failure: &lndclient.HtlcFailure{Code: lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE, FailureSourceIndex: 2}},
+				},
+			},
+			want: Payment{
+				Route:         hops,
+				FailureCode:   "TEMPORARY_CHANNEL_FAILURE",
+				FailureSource: "C",
+			},
+		},
+		{
+			name: "no attempts",
+			status: lndclient.PaymentStatus{
+				State: lnrpc.Payment_FAILED,
+			},
+			want: Payment{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := payment(tt.status); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLndClient_ProbeRoute(t *testing.T) {
+	r := Route{
+		Hops: []RouteHop{
+			{Hop: Hop{ChannelID: 1, PubKey: "B"}, Forward: 1000, Fee: 1},
+			{Hop: Hop{ChannelID: 2, PubKey: "A"}, Forward: 1000},
+		},
+		Amount: 1000,
+		Fee:    1,
+	}
+
+	tests := []struct {
+		name    string
+		failure *lnrpc.Failure
+		want    Probe
+		wantErr bool
+	}{
+		{
+			name:    "reached destination",
+			failure: &lnrpc.Failure{Code: lnrpc.Failure_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS, FailureSourceIndex: 2},
+			want:    Probe{Reached: true, FailureCode: "INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS", FailureSource: "A"},
+		},
+		{
+			name:    "failed along the way",
+			failure: &lnrpc.Failure{Code: lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE, FailureSourceIndex: 1},
+			want:    Probe{FailureCode: "TEMPORARY_CHANNEL_FAILURE", FailureSource: "B"},
+		},
+		{
+			name:    "settled",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent *routerrpc.SendToRouteRequest
+			var deleted []byte
+			l := LndClient{
+				p: &patherMock{
+					SendToRouteV2Func: func(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error) {
+						sent = in
+						return &lnrpc.HTLCAttempt{Failure: tt.failure}, nil
+					},
+					DeletePaymentFunc: func(ctx context.Context, in *lnrpc.DeletePaymentRequest) (*lnrpc.DeletePaymentResponse, error) {
+						deleted = in.PaymentHash
+						return &lnrpc.DeletePaymentResponse{}, nil
+					},
+				},
+			}
+			got, err := l.ProbeRoute(context.Background(), r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LndClient.ProbeRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LndClient.ProbeRoute() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(newRoute(sent.Route), r) {
+				t.Errorf("LndClient.ProbeRoute() sent %v, want %v", newRoute(sent.Route), r)
+			}
+			// the failed probe is cleaned out of lnd's payments
+			if sent.SkipTempErr || !bytes.Equal(deleted, sent.PaymentHash) {
+				t.Errorf("LndClient.ProbeRoute() deleted %x after probing %x, want the probe failed and deleted", deleted, sent.PaymentHash)
+			}
+		})
+	}
+}
+
+func TestLndClient_AddInvoice(t *testing.T) {
+	var added *invoicesrpc.AddInvoiceData
+	l := LndClient{
+		i: &invoicerMock{
+			AddInvoiceFunc: func(ctx context.Context, in *invoicesrpc.AddInvoiceData) (lntypes.Hash, string, error) {
+				added = in
+				return lntypes.Hash{}, "invoice", nil
+			},
+		},
+	}
+
+	got, err := l.AddInvoice(context.Background(), 10, "raiju rebalance 1:2", 1500*time.Millisecond)
+	if err != nil || got != "invoice" {
+		t.Fatalf("LndClient.AddInvoice() = %v, %v, want invoice", got, err)
+	}
+	// expiry rounds up so the invoice never expires early
+	if added.Memo != "raiju rebalance 1:2" || added.Expiry != 2 || added.Value != 10_000 {
+		t.Errorf("LndClient.AddInvoice() added %+v, want the memo, a 2 second expiry, and 10 sats", added)
+	}
+}
+
+func TestLndClient_BatchOpenChannel(t *testing.T) {
+	accepting := route.Vertex([33]byte{1})
+	rejecting := route.Vertex([33]byte{2})
+	closing := route.Vertex([33]byte{3})
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(&wire.TxOut{Value: 1000})
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// open streams by peer, which accept the channel unless they reject it or close the stream early
+	stream := func(peer route.Vertex) (<-chan *lndclient.OpenStatusUpdate, <-chan error) {
+		updates := make(chan *lndclient.OpenStatusUpdate, 2)
+		errs := make(chan error, 1)
+		switch {
+		case peer == rejecting:
+			errs <- errors.New("channel rejected")
+		case peer == closing:
+			updates <- &lndclient.OpenStatusUpdate{PsbtFund: &lnrpc.ReadyForPsbtFunding{FundingAddress: peer.String(), FundingAmount: 1000}}
+			close(updates)
+		default:
+			updates <- &lndclient.OpenStatusUpdate{PsbtFund: &lnrpc.ReadyForPsbtFunding{FundingAddress: peer.String(), FundingAmount: 1000}}
+			updates <- &lndclient.OpenStatusUpdate{ChanPending: &lnrpc.PendingUpdate{}}
+		}
+		return updates, errs
+	}
+
+	tests := []struct {
+		name          string
+		peers         []route.Vertex
+		want          string
+		wantErr       bool
+		wantCancels   int
+		wantFinalizes int
+	}{
+		{
+			name:          "all peers accept",
+			peers:         []route.Vertex{accepting, accepting},
+			want:          tx.TxHash().String(),
+			wantFinalizes: 2,
+		},
+		{
+			name:        "one peer rejects",
+			peers:       []route.Vertex{accepting, rejecting},
+			wantErr:     true,
+			wantCancels: 2,
+		},
+		{
+			name:          "stream closes early",
+			peers:         []route.Vertex{accepting, closing},
+			wantErr:       true,
+			wantFinalizes: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cancels, finalizes int
+			l := LndClient{
+				c: &channelerMock{
+					OpenChannelStreamFunc: func(ctx context.Context, peer route.Vertex, localSat btcutil.Amount, pushSat btcutil.Amount, private bool, opts ...lndclient.OpenChannelOption) (<-chan *lndclient.OpenStatusUpdate, <-chan error, error) {
+						updates, errs := stream(peer)
+						return updates, errs, nil
+					},
+					FundingStateStepFunc: func(ctx context.Context, req *lnrpc.FundingTransitionMsg) (*lnrpc.FundingStateStepResp, error) {
+						switch req.Trigger.(type) {
+						case *lnrpc.FundingTransitionMsg_ShimCancel:
+							cancels++
+						case *lnrpc.FundingTransitionMsg_PsbtFinalize:
+							finalizes++
+						}
+						return &lnrpc.FundingStateStepResp{}, nil
+					},
+				},
+				w: &walleterMock{
+					FundPsbtFunc: func(ctx context.Context, req *walletrpc.FundPsbtRequest) (*psbt.Packet, int32, []*walletrpc.UtxoLease, error) {
+						return packet, 0, nil, nil
+					},
+					FinalizePsbtFunc: func(ctx context.Context, p *psbt.Packet, account string) (*psbt.Packet, *wire.MsgTx, error) {
+						return packet, tx, nil
+					},
+				},
+			}
+
+			channels := make([]NewChannel, len(tt.peers))
+			for i, p := range tt.peers {
+				channels[i] = NewChannel{PubKey: PubKey(p.String()), Amount: 1000}
+			}
+
+			got, err := l.BatchOpenChannel(context.Background(), channels, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LndClient.BatchOpenChannel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LndClient.BatchOpenChannel() = %v, want %v", got, tt.want)
+			}
+			if cancels != tt.wantCancels || finalizes != tt.wantFinalizes {
+				t.Errorf("LndClient.BatchOpenChannel() canceled %d and finalized %d, want %d and %d", cancels, finalizes, tt.wantCancels, tt.wantFinalizes)
+			}
+		})
+	}
+}
+
+// testInvoice for amount on regtest, along with its payment hash and address.
+func testInvoice(t *testing.T, amount MilliSatoshi) (Invoice, [32]byte, [32]byte) {
+	t.Helper()
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := [32]byte{1}
+	addr := [32]byte{2}
+
+	i, err := zpay32.NewInvoice(&chaincfg.RegressionNetParams, hash, time.Now(),
+		zpay32.Amount(lnwire.MilliSatoshi(amount)), zpay32.Description("test"), zpay32.PaymentAddr(addr), zpay32.CLTVExpiry(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := i.Encode(zpay32.MessageSigner{
+		SignCompact: func(msg []byte) ([]byte, error) {
+			return ecdsa.SignCompact(key, chainhash.HashB(msg), true)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Invoice(encoded), hash, addr
+}
+
+func TestLndClient_BuildRoute(t *testing.T) {
+	invoice, _, _ := testInvoice(t, 10_000)
+	b := route.Vertex([33]byte{2})
+	c := route.Vertex([33]byte{3})
+
+	built := &lnrpc.Route{
+		TotalTimeLock: 100,
+		TotalAmtMsat:  10_001,
+		TotalFeesMsat: 1,
+		Hops: []*lnrpc.Hop{
+			{ChanId: 1, PubKey: b.String(), AmtToForwardMsat: 10_000, FeeMsat: 1, Expiry: 140},
+			{ChanId: 2, PubKey: c.String(), AmtToForwardMsat: 10_000, Expiry: 140},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		hops     []PubKey
+		buildErr error
+		want     Route
+		wantErr  bool
+	}{
+		{
+			name: "built from the invoice",
+			hops: []PubKey{PubKey(b.String()), PubKey(c.String())},
+			want: Route{
+				Hops: []RouteHop{
+					{Hop: Hop{ChannelID: 1, PubKey: PubKey(b.String())}, Forward: 10_000, Fee: 1, Expiry: 140},
+					{Hop: Hop{ChannelID: 2, PubKey: PubKey(c.String())}, Forward: 10_000, Expiry: 140},
+				},
+				Amount:   10_000,
+				Fee:      1,
+				TimeLock: 100,
+			},
+		},
+		{
+			name:    "bad hop",
+			hops:    []PubKey{"B"},
+			wantErr: true,
+		},
+		{
+			name:     "no route",
+			hops:     []PubKey{PubKey(b.String()), PubKey(c.String())},
+			buildErr: errors.New("no route"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *routerrpc.BuildRouteRequest
+			l := LndClient{
+				network: "regtest",
+				p: &patherMock{
+					BuildRouteFunc: func(ctx context.Context, in *routerrpc.BuildRouteRequest) (*routerrpc.BuildRouteResponse, error) {
+						req = in
+						return &routerrpc.BuildRouteResponse{Route: built}, tt.buildErr
+					},
+				},
+			}
+
+			got, err := l.BuildRoute(context.Background(), invoice, 1, tt.hops)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LndClient.BuildRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LndClient.BuildRoute() = %v, want %v", got, tt.want)
+			}
+			if req.AmtMsat != 10_000 || req.FinalCltvDelta != 40 || req.OutgoingChanId != 1 || !reflect.DeepEqual(req.HopPubkeys, [][]byte{b[:], c[:]}) {
+				t.Errorf("LndClient.BuildRoute() requested %v, want the invoice's amount and expiry out channel 1 through B and C", req)
+			}
+		})
+	}
+}
+
+func TestLndClient_SendToRoute(t *testing.T) {
+	invoice, hash, addr := testInvoice(t, 10_000)
+
+	r := Route{
+		Hops: []RouteHop{
+			{Hop: Hop{ChannelID: 1, PubKey: "B"}, Forward: 10_000, Fee: 2001},
+			{Hop: Hop{ChannelID: 2, PubKey: "C"}, Forward: 10_000},
+		},
+		Amount: 10_000,
+		Fee:    2001,
+	}
+
+	tests := []struct {
+		name    string
+		attempt *lnrpc.HTLCAttempt
+		want    Payment
+		wantErr bool
+	}{
+		{
+			name:    "succeeded rounding the fee up",
+			attempt: &lnrpc.HTLCAttempt{Status: lnrpc.HTLCAttempt_SUCCEEDED},
+			want:    Payment{Fee: 3, Route: []Hop{{ChannelID: 1, PubKey: "B"}, {ChannelID: 2, PubKey: "C"}}},
+		},
+		{
+			name: "failed along the way",
+			attempt: &lnrpc.HTLCAttempt{
+				Status:  lnrpc.HTLCAttempt_FAILED,
+				Failure: &lnrpc.Failure{Code: lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE, FailureSourceIndex: 1},
+			},
+			want: Payment{
+				Route:         []Hop{{ChannelID: 1, PubKey: "B"}, {ChannelID: 2, PubKey: "C"}},
+				FailureCode:   "TEMPORARY_CHANNEL_FAILURE",
+				FailureSource: "B",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent *routerrpc.SendToRouteRequest
+			l := LndClient{
+				network: "regtest",
+				p: &patherMock{
+					SendToRouteV2Func: func(ctx context.Context, in *routerrpc.SendToRouteRequest) (*lnrpc.HTLCAttempt, error) {
+						sent = in
+						return tt.attempt, nil
+					},
+				},
+			}
+
+			got, err := l.SendToRoute(context.Background(), invoice, r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LndClient.SendToRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LndClient.SendToRoute() = %v, want %v", got, tt.want)
+			}
+
+			if !bytes.Equal(sent.PaymentHash, hash[:]) {
+				t.Errorf("LndClient.SendToRoute() paid hash %x, want %x", sent.PaymentHash, hash)
+			}
+			// only the destination gets the payment address
+			hops := sent.Route.Hops
+			if hops[0].MppRecord != nil {
+				t.Errorf("LndClient.SendToRoute() first hop MPP record = %v, want none", hops[0].MppRecord)
+			}
+			if mpp := hops[len(hops)-1].MppRecord; mpp == nil || !bytes.Equal(mpp.PaymentAddr, addr[:]) || mpp.TotalAmtMsat != 10_000 {
+				t.Errorf("LndClient.SendToRoute() last hop MPP record = %v, want the invoice's address and amount", mpp)
+			}
+		})
+	}
+}
+
+func TestLndClient_CancelInvoice(t *testing.T) {
+	invoice, hash, _ := testInvoice(t, 10_000)
+
+	tests := []struct {
+		name      string
+		invoice   Invoice
+		cancelErr error
+		wantErr   bool
+	}{
+		{
+			name:    "canceled by hash",
+			invoice: invoice,
+		},
+		{
+			name:      "already settled",
+			invoice:   invoice,
+			cancelErr: errors.New("invoice already settled"),
+			wantErr:   true,
+		},
+		{
+			name:    "not an invoice",
+			invoice: "invoice",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var canceled lntypes.Hash
+			l := LndClient{
+				network: "regtest",
+				i: &invoicerMock{
+					CancelInvoiceFunc: func(ctx context.Context, h lntypes.Hash) error {
+						canceled = h
+						return tt.cancelErr
+					},
+				},
+			}
+
+			err := l.CancelInvoice(context.Background(), tt.invoice)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LndClient.CancelInvoice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.invoice == invoice && canceled != lntypes.Hash(hash) {
+				t.Errorf("LndClient.CancelInvoice() canceled %v, want %v", canceled, lntypes.Hash(hash))
+			}
+		})
+	}
+}
+
+func TestLndClient_SettledInvoices(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+
+	// the newest page is full, so the one before it is pulled too
+	newest := make([]lndclient.Invoice, invoicesPage)
+	for i := range newest {
+		newest[i] = lndclient.Invoice{
+			Memo:         "newer",
+			AmountPaid:   1000,
+			CreationDate: now,
+			SettleDate:   now,
+			State:        invpkg.ContractSettled,
+		}
+	}
+	newest[invoicesPage-1].State = invpkg.ContractOpen
+	older := []lndclient.Invoice{
+		{Memo: "too old", AmountPaid: 1000, CreationDate: since.Add(-time.Hour), SettleDate: since.Add(-time.Hour), State: invpkg.ContractSettled},
+		{Memo: "oldest", AmountPaid: 2500, CreationDate: since.Add(time.Hour), SettleDate: since.Add(time.Hour), State: invpkg.ContractSettled},
+		{Memo: "canceled", CreationDate: since.Add(time.Hour), State: invpkg.ContractCanceled},
+	}
+
+	var offsets []uint64
+	l := LndClient{
+		i: &invoicerMock{
+			ListInvoicesFunc: func(ctx context.Context, req lndclient.ListInvoicesRequest) (*lndclient.ListInvoicesResponse, error) {
+				if !req.Reversed {
+					t.Errorf("LndClient.SettledInvoices() listed oldest first")
+				}
+				offsets = append(offsets, req.Offset)
+				if req.Offset == 0 {
+					return &lndclient.ListInvoicesResponse{FirstIndexOffset: 4, Invoices: newest}, nil
+				}
+				return &lndclient.ListInvoicesResponse{FirstIndexOffset: 1, Invoices: older}, nil
+			},
+		},
+	}
+
+	got, err := l.SettledInvoices(context.Background(), since)
+	if err != nil {
+		t.Fatalf("LndClient.SettledInvoices() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(offsets, []uint64{0, 4}) {
+		t.Errorf("LndClient.SettledInvoices() pulled offsets %v, want [0 4]", offsets)
+	}
+	if len(got) != invoicesPage {
+		t.Fatalf("LndClient.SettledInvoices() = %d invoices, want %d", len(got), invoicesPage)
+	}
+	if got[0] != (SettledInvoice{Memo: "newer", Amount: 1, Settled: now}) {
+		t.Errorf("LndClient.SettledInvoices() newest = %v", got[0])
+	}
+	if last := got[len(got)-1]; last != (SettledInvoice{Memo: "oldest", Amount: 2, Settled: since.Add(time.Hour)}) {
+		t.Errorf("LndClient.SettledInvoices() oldest = %v", last)
+	}
+}
@@ -0,0 +1,98 @@
+package raiju
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// aliasPrefix marks a node list entry as an alias regular expression instead of a pubkey.
+const aliasPrefix = "alias:"
+
+// NodeList matches nodes by pubkey or alias.
+type NodeList struct {
+	PubKeys []lightning.PubKey
+	// Aliases are regular expressions matched against a node's alias
+	Aliases []*regexp.Regexp
+}
+
+// Empty is true if the list matches no nodes.
+func (nl NodeList) Empty() bool {
+	return len(nl.PubKeys) == 0 && len(nl.Aliases) == 0
+}
+
+// Match is true if the node's pubkey is in the list or its alias matches one of the expressions.
+func (nl NodeList) Match(node lightning.Node) bool {
+	for _, p := range nl.PubKeys {
+		if p == node.PubKey {
+			return true
+		}
+	}
+
+	for _, a := range nl.Aliases {
+		if a.MatchString(node.Alias) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseNodeList with one entry per line.
+//
+// Entries are pubkeys, or alias regular expressions prefixed with "alias:". Blank lines and lines starting with "#"
+// are ignored.
+func ParseNodeList(r io.Reader) (NodeList, error) {
+	var nl NodeList
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if alias, found := strings.CutPrefix(line, aliasPrefix); found {
+			re, err := regexp.Compile(alias)
+			if err != nil {
+				return NodeList{}, fmt.Errorf("invalid alias expression %s: %w", alias, err)
+			}
+			nl.Aliases = append(nl.Aliases, re)
+		} else {
+			nl.PubKeys = append(nl.PubKeys, lightning.PubKey(line))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return NodeList{}, err
+	}
+
+	return nl, nil
+}
+
+// ReadNodeList from a file in the ParseNodeList format.
+func ReadNodeList(path string) (NodeList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NodeList{}, err
+	}
+	defer f.Close()
+
+	return ParseNodeList(f)
+}
+
+// WriteNodeList of nodes in the ParseNodeList format, each pubkey commented with its alias.
+func WriteNodeList(w io.Writer, nodes []lightning.Node) error {
+	for _, n := range nodes {
+		if _, err := fmt.Fprintf(w, "# %s\n%s\n", n.Alias, n.PubKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
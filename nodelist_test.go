@@ -0,0 +1,123 @@
+package raiju
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestNodeList_Match(t *testing.T) {
+	tests := []struct {
+		name string
+		nl   NodeList
+		node lightning.Node
+		want bool
+	}{
+		{
+			name: "empty list matches nothing",
+			nl:   NodeList{},
+			node: lightning.Node{PubKey: pubKeyA, Alias: "A"},
+			want: false,
+		},
+		{
+			name: "pubkey match",
+			nl:   NodeList{PubKeys: []lightning.PubKey{pubKeyB, pubKeyA}},
+			node: lightning.Node{PubKey: pubKeyA, Alias: "A"},
+			want: true,
+		},
+		{
+			name: "alias match",
+			nl:   NodeList{Aliases: []*regexp.Regexp{regexp.MustCompile("(?i)^ack")}},
+			node: lightning.Node{PubKey: pubKeyA, Alias: "ACKbot"},
+			want: true,
+		},
+		{
+			name: "no match",
+			nl: NodeList{
+				PubKeys: []lightning.PubKey{pubKeyB},
+				Aliases: []*regexp.Regexp{regexp.MustCompile("^B$")},
+			},
+			node: lightning.Node{PubKey: pubKeyA, Alias: "A"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.nl.Match(tt.node); got != tt.want {
+				t.Errorf("NodeList.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNodeList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    NodeList
+		wantErr bool
+	}{
+		{
+			name:  "pubkeys and aliases",
+			input: "# rejected peers\nA\n\n  B  \nalias:^spam\n",
+			want: NodeList{
+				PubKeys: []lightning.PubKey{pubKeyA, pubKeyB},
+				Aliases: []*regexp.Regexp{regexp.MustCompile("^spam")},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			want:    NodeList{},
+			wantErr: false,
+		},
+		{
+			name:    "invalid alias expression",
+			input:   "alias:(",
+			want:    NodeList{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNodeList(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseNodeList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNodeList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteNodeList(t *testing.T) {
+	nodes := []lightning.Node{
+		{PubKey: pubKeyA, Alias: "a"},
+		{PubKey: pubKeyB, Alias: "b"},
+	}
+
+	var b bytes.Buffer
+	if err := WriteNodeList(&b, nodes); err != nil {
+		t.Fatalf("WriteNodeList() error = %v", err)
+	}
+
+	want := "# a\nA\n# b\nB\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteNodeList() = %q, want %q", got, want)
+	}
+
+	nl, err := ParseNodeList(&b)
+	if err != nil {
+		t.Fatalf("ParseNodeList() error = %v", err)
+	}
+	if !reflect.DeepEqual(nl.PubKeys, []lightning.PubKey{pubKeyA, pubKeyB}) {
+		t.Errorf("ParseNodeList() = %v, want written pubkeys", nl.PubKeys)
+	}
+}
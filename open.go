@@ -0,0 +1,89 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// OpenRequest contains the channels to open and the limits on opening them.
+type OpenRequest struct {
+	Channels []lightning.NewChannel
+	// FeeRate of the funding transaction, zero lets the node estimate it
+	FeeRate lightning.SatPerVByte
+	// MaxSpend is the cap on the total satoshis committed to channels in a single run
+	MaxSpend lightning.Satoshi
+	// Batch funds all the channels with a single transaction
+	Batch bool
+}
+
+// Open channels to new peers, returns the funding transaction IDs.
+//
+// Peers are connected to at their announced addresses first. Unbatched channels are opened one at a time, so a
+// failure returns the transactions of the channels which were already opened along with the error.
+func (r Raiju) Open(ctx context.Context, request OpenRequest) ([]string, error) {
+	if len(request.Channels) == 0 {
+		return nil, errors.New("no channels to open")
+	}
+
+	var total lightning.Satoshi
+	for _, c := range request.Channels {
+		if c.Amount <= 0 {
+			return nil, fmt.Errorf("channel amount to %s must be positive", c.PubKey)
+		}
+		total += c.Amount
+	}
+
+	if total > request.MaxSpend {
+		return nil, fmt.Errorf("total of %d sats exceeds the max spend of %d sats", total, request.MaxSpend)
+	}
+
+	for _, c := range request.Channels {
+		if err := r.connect(ctx, c.PubKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Batch {
+		txid, err := r.l.BatchOpenChannel(ctx, request.Channels, request.FeeRate)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{txid}, nil
+	}
+
+	txids := make([]string, 0, len(request.Channels))
+	for _, c := range request.Channels {
+		txid, err := r.l.OpenChannel(ctx, c, request.FeeRate)
+		if err != nil {
+			return txids, fmt.Errorf("unable to open channel to %s: %w", c.PubKey, err)
+		}
+
+		txids = append(txids, txid)
+	}
+
+	return txids, nil
+}
+
+// connect to a node at the first of its announced addresses which accepts.
+func (r Raiju) connect(ctx context.Context, pubKey lightning.PubKey) error {
+	node, err := r.l.GetNode(ctx, pubKey)
+	if err != nil {
+		return fmt.Errorf("unable to get node %s: %w", pubKey, err)
+	}
+
+	if len(node.Addresses) == 0 {
+		return fmt.Errorf("node %s has no announced addresses", pubKey)
+	}
+
+	for _, a := range node.Addresses {
+		if err = r.l.ConnectPeer(ctx, pubKey, a); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to connect to node %s: %w", pubKey, err)
+}
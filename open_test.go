@@ -0,0 +1,180 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_Open(t *testing.T) {
+	channels := []lightning.NewChannel{
+		{PubKey: pubKeyA, Amount: 1000000},
+		{PubKey: pubKeyB, Amount: 2000000},
+	}
+
+	type fields struct {
+		l lightninger
+	}
+	type args struct {
+		ctx     context.Context
+		request OpenRequest
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "opens channels one at a time",
+			fields: fields{
+				l: &lightningerMock{
+					GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+						return lightning.Node{PubKey: pubKey, Addresses: []string{clearnetAddress}}, nil
+					},
+					OpenChannelFunc: func(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+						return string(channel.PubKey), nil
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels,
+					MaxSpend: 3000000,
+				},
+			},
+			want:    []string{string(pubKeyA), string(pubKeyB)},
+			wantErr: false,
+		},
+		{
+			name: "batch opens channels in one transaction",
+			fields: fields{
+				l: &lightningerMock{
+					GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+						return lightning.Node{PubKey: pubKey, Addresses: []string{clearnetAddress}}, nil
+					},
+					BatchOpenChannelFunc: func(ctx context.Context, channels []lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+						return "batch", nil
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels,
+					MaxSpend: 3000000,
+					Batch:    true,
+				},
+			},
+			want:    []string{"batch"},
+			wantErr: false,
+		},
+		{
+			name: "total over the max spend is rejected",
+			fields: fields{
+				l: &lightningerMock{},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels,
+					MaxSpend: 2999999,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "tries the next address if a connection fails",
+			fields: fields{
+				l: &lightningerMock{
+					GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+						return lightning.Node{PubKey: pubKey, Addresses: []string{torAddress, clearnetAddress}}, nil
+					},
+					ConnectPeerFunc: func(ctx context.Context, pubKey lightning.PubKey, address string) error {
+						if address == torAddress {
+							return errors.New("unreachable")
+						}
+						return nil
+					},
+					OpenChannelFunc: func(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+						return string(channel.PubKey), nil
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels[:1],
+					MaxSpend: 3000000,
+				},
+			},
+			want:    []string{string(pubKeyA)},
+			wantErr: false,
+		},
+		{
+			name: "node without addresses can't be connected to",
+			fields: fields{
+				l: &lightningerMock{
+					GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+						return lightning.Node{PubKey: pubKey}, nil
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels,
+					MaxSpend: 3000000,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "opened channels are returned on failure",
+			fields: fields{
+				l: &lightningerMock{
+					GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+						return lightning.Node{PubKey: pubKey, Addresses: []string{clearnetAddress}}, nil
+					},
+					OpenChannelFunc: func(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+						if channel.PubKey == pubKeyB {
+							return "", errors.New("rejected")
+						}
+						return string(channel.PubKey), nil
+					},
+				},
+			},
+			args: args{
+				ctx: context.Background(),
+				request: OpenRequest{
+					Channels: channels,
+					MaxSpend: 3000000,
+				},
+			},
+			want:    []string{string(pubKeyA)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Raiju{
+				l: tt.fields.l,
+			}
+			got, err := r.Open(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Raiju.Open() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Raiju.Open() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
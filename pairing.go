@@ -0,0 +1,131 @@
+package raiju
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// PairStrategy orders the channel pairs a rebalance tries.
+type PairStrategy string
+
+const (
+	// ValueStrategy tries the pairs expected to earn the most per sat moved first and skips unprofitable ones.
+	ValueStrategy PairStrategy = "value"
+	// RandomStrategy tries every pair in a random order.
+	RandomStrategy PairStrategy = "random"
+)
+
+// unknownFeeRatio of the max fee assumed to be paid when there is no history to estimate from.
+const unknownFeeRatio = 0.5
+
+// ParsePairStrategy from its name.
+func ParsePairStrategy(s string) (PairStrategy, error) {
+	switch PairStrategy(s) {
+	case ValueStrategy, RandomStrategy:
+		return PairStrategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown pair strategy: %s", s)
+	}
+}
+
+// rebalancePair of a high liquidity channel to move sats out of and a low liquidity channel to move them into.
+type rebalancePair struct {
+	out lightning.Channel
+	in  lightning.Channel
+	// value expected in ppm of the sats moved
+	value float64
+}
+
+// pairs of high and low liquidity channels in the order the request's strategy wants them tried.
+func (r Raiju) pairs(high lightning.Channels, low lightning.Channels, request RebalanceRequest) []rebalancePair {
+	if request.Strategy == RandomStrategy {
+		return randomPairs(high, low)
+	}
+
+	return r.valuePairs(high, low, request)
+}
+
+// randomPairs shuffles the high liquidity channels and then the low ones for each, so different combos are tried.
+func randomPairs(high lightning.Channels, low lightning.Channels) []rebalancePair {
+	hlcs := append(lightning.Channels{}, high...)
+	llcs := append(lightning.Channels{}, low...)
+
+	rand.Shuffle(len(hlcs), func(i, j int) {
+		hlcs[i], hlcs[j] = hlcs[j], hlcs[i]
+	})
+
+	pairs := make([]rebalancePair, 0, len(hlcs)*len(llcs))
+	for _, h := range hlcs {
+		rand.Shuffle(len(llcs), func(i, j int) {
+			llcs[i], llcs[j] = llcs[j], llcs[i]
+		})
+		for _, l := range llcs {
+			pairs = append(pairs, rebalancePair{out: h, in: l})
+		}
+	}
+
+	return pairs
+}
+
+// valuePairs with a positive expected value, the most valuable and imbalanced first.
+//
+// Sats moved out of a high liquidity channel stop earning its fee and start earning the low liquidity channel's fee,
+// minus the cost of the rebalance. Past attempts between the pair estimate the chance of success and the cost.
+func (r Raiju) valuePairs(high lightning.Channels, low lightning.Channels, request RebalanceRequest) []rebalancePair {
+	stats := make(map[[2]lightning.ChannelID]PairStats)
+	var overall PairStats
+	for _, s := range request.History.Pairs() {
+		stats[[2]lightning.ChannelID{s.Out, s.In}] = s
+		overall.Moved += s.Moved
+		overall.Fees += s.Fees
+	}
+
+	pairs := make([]rebalancePair, 0, len(high)*len(low))
+	for _, h := range high {
+		for _, l := range low {
+			// nothing to gain moving liquidity towards a channel which already has as much, sticky fees aside
+			if h.ChannelID == l.ChannelID || imbalance(h, l) <= 0 {
+				continue
+			}
+
+			s := stats[[2]lightning.ChannelID{h.ChannelID, l.ChannelID}]
+
+			cost := float64(request.MaxFee) * unknownFeeRatio
+			if s.Moved > 0 {
+				cost = effectivePPM(s.Fees, s.Moved)
+			} else if overall.Moved > 0 {
+				cost = effectivePPM(overall.Fees, overall.Moved)
+			}
+			earned := float64(r.f.Fee(l)) - float64(r.f.Fee(h)) - cost
+
+			// smooth the success rate so untried pairs aren't written off
+			success := float64(s.Successes+1) / float64(s.Attempts+2)
+
+			value := success * earned
+			if value <= 0 {
+				continue
+			}
+
+			pairs = append(pairs, rebalancePair{out: h, in: l, value: value * imbalance(h, l)})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].value > pairs[j].value
+	})
+
+	return pairs
+}
+
+// effectivePPM of the fees paid to move sats.
+func effectivePPM(fees lightning.Satoshi, moved lightning.Satoshi) float64 {
+	return float64(fees) / float64(moved) * 1_000_000
+}
+
+// imbalance of a pair, from zero when both channels are balanced to one when they are completely lopsided.
+func imbalance(high lightning.Channel, low lightning.Channel) float64 {
+	return ((high.Liquidity() - 50) + (50 - low.Liquidity())) / 100
+}
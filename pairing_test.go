@@ -0,0 +1,93 @@
+package raiju
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_valuePairs(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 10)
+
+	high := lightning.Channels{
+		{Edge: lightning.Edge{Capacity: 100}, ChannelID: 1, LocalBalance: 90, LocalFee: 5},
+		{Edge: lightning.Edge{Capacity: 100}, ChannelID: 2, LocalBalance: 85, LocalFee: 5},
+	}
+	low := lightning.Channels{
+		{Edge: lightning.Edge{Capacity: 100}, ChannelID: 3, LocalBalance: 10, LocalFee: 500},
+		{Edge: lightning.Edge{Capacity: 100}, ChannelID: 4, LocalBalance: 15, LocalFee: 500},
+	}
+
+	failures := RebalanceHistory{
+		Attempts: []RebalanceAttempt{
+			{Out: 2, In: 4},
+			{Out: 2, In: 4},
+			{Out: 2, In: 4},
+			{Out: 2, In: 4},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		high    lightning.Channels
+		low     lightning.Channels
+		request RebalanceRequest
+		want    [][2]lightning.ChannelID
+	}{
+		{
+			name:    "most imbalanced first and failing pairs last",
+			request: RebalanceRequest{MaxFee: 500, History: failures},
+			want:    [][2]lightning.ChannelID{{1, 3}, {1, 4}, {2, 3}, {2, 4}},
+		},
+		{
+			name:    "expected fees eat all the value",
+			request: RebalanceRequest{MaxFee: 1000},
+			want:    [][2]lightning.ChannelID{},
+		},
+		{
+			name: "expensive history drops the pair",
+			request: RebalanceRequest{
+				MaxFee: 500,
+				History: RebalanceHistory{
+					Attempts: []RebalanceAttempt{{Out: 1, In: 3, Amount: 1000, Fee: 1, Succeeded: true}},
+				},
+			},
+			want: [][2]lightning.ChannelID{},
+		},
+		{
+			name: "sticky fees can't make a pair which is already balanced the wrong way valuable",
+			high: lightning.Channels{{Edge: lightning.Edge{Capacity: 100}, ChannelID: 5, LocalBalance: 21, LocalFee: 50}},
+			// still holding on to its low liquidity fee
+			low:     lightning.Channels{{Edge: lightning.Edge{Capacity: 100}, ChannelID: 6, LocalBalance: 25, LocalFee: 500}},
+			request: RebalanceRequest{MaxFee: 10},
+			want:    [][2]lightning.ChannelID{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, l := high, low
+			if tt.high != nil {
+				h, l = tt.high, tt.low
+			}
+
+			r := Raiju{f: f}
+			got := [][2]lightning.ChannelID{}
+			for _, p := range r.valuePairs(h, l, tt.request) {
+				got = append(got, [2]lightning.ChannelID{p.out.ChannelID, p.in.ChannelID})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Raiju.valuePairs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePairStrategy(t *testing.T) {
+	if got, err := ParsePairStrategy("random"); err != nil || got != RandomStrategy {
+		t.Errorf("ParsePairStrategy() = %v, %v, want %v", got, err, RandomStrategy)
+	}
+	if _, err := ParsePairStrategy("greedy"); err == nil {
+		t.Error("ParsePairStrategy() expected error for unknown strategy")
+	}
+}
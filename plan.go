@@ -0,0 +1,227 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// PlanRequest contains the constraints of a batch of channels to open.
+type PlanRequest struct {
+	// Candidates ranks the nodes considered at each step, planned channels are added to its assumes
+	Candidates CandidatesRequest
+	// Channels is the max number of channels to open
+	Channels int
+	// Budget is the total satoshis to spend on all channels
+	Budget lightning.Satoshi
+	// MinChannelSize of a single channel
+	MinChannelSize lightning.Satoshi
+	// MaxChannelSize of a single channel
+	MaxChannelSize lightning.Satoshi
+	// Beam is the number of partial plans kept at each step, 1 is a greedy search
+	Beam int
+}
+
+// PlannedChannel to open.
+type PlannedChannel struct {
+	// RelativeNode stats at the time the channel was planned
+	RelativeNode
+	Amount lightning.Satoshi
+}
+
+// Plan of channels which can be opened in a single batch transaction.
+type Plan struct {
+	Channels []PlannedChannel
+	// MeanDistance from the root node to all reachable nodes once the channels are open
+	MeanDistance float64
+}
+
+// Total satoshis of all planned channels.
+func (p Plan) Total() lightning.Satoshi {
+	var total lightning.Satoshi
+	for _, c := range p.Channels {
+		total += c.Amount
+	}
+
+	return total
+}
+
+// step in the search for the best plan.
+type step struct {
+	assume       []lightning.PubKey
+	chosen       []RelativeNode
+	meanDistance float64
+}
+
+// key is the same for steps with the same channels, no matter what order they were chosen in.
+func (s step) key() string {
+	keys := make([]string, len(s.chosen))
+	for i, c := range s.chosen {
+		keys[i] = string(c.PubKey)
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, ",")
+}
+
+// channelSize splits the budget evenly across channels, dropping channels if the budget can't cover the minimum size.
+func channelSize(request PlanRequest) (int, lightning.Satoshi, error) {
+	channels := request.Channels
+	size := request.Budget / lightning.Satoshi(channels)
+
+	if size < request.MinChannelSize {
+		channels = int(request.Budget / request.MinChannelSize)
+		if channels == 0 {
+			return 0, 0, errors.New("budget does not cover the minimum channel size")
+		}
+		size = request.Budget / lightning.Satoshi(channels)
+	}
+
+	if size > request.MaxChannelSize {
+		size = request.MaxChannelSize
+	}
+
+	return channels, size, nil
+}
+
+// meanDistance from the root to all the nodes it can reach.
+func meanDistance(nodes map[lightning.PubKey]*RelativeNode) float64 {
+	var total, reachable int64
+	for _, n := range nodes {
+		// unreachable nodes and the root have no distance
+		if n.Distance > 0 {
+			total += n.Distance
+			reachable++
+		}
+	}
+
+	if reachable == 0 {
+		return 0
+	}
+
+	return float64(total) / float64(reachable)
+}
+
+// Plan a batch of channels to open.
+//
+// Channels are picked one at a time, re-calculating candidates with the already picked channels assumed. Each step
+// the top candidates of the best partial plans are tried, keeping the plans which bring the rest of the network
+// closest to the root node.
+func (r Raiju) Plan(ctx context.Context, request PlanRequest) (Plan, error) {
+	if request.Channels < 1 {
+		return Plan{}, errors.New("plan requires at least one channel")
+	}
+
+	if request.Budget <= 0 {
+		return Plan{}, errors.New("budget must be positive")
+	}
+
+	if request.MaxChannelSize <= 0 {
+		return Plan{}, errors.New("max channel size must be positive")
+	}
+
+	if request.MinChannelSize > request.MaxChannelSize {
+		return Plan{}, errors.New("min channel size must not be greater than max channel size")
+	}
+
+	if request.Beam < 1 {
+		request.Beam = 1
+	}
+
+	channels, size, err := channelSize(request)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	request.Candidates, err = r.localize(ctx, request.Candidates)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	// pull the graph once and re-calculate against it
+	channelGraph, err := r.l.DescribeGraph(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	nodes, err := relativeNodes(channelGraph, request.Candidates)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	steps := []step{{assume: request.Candidates.Assume, meanDistance: meanDistance(nodes)}}
+	for i := 0; i < channels; i++ {
+		next := make([]step, 0)
+		seen := make(map[string]bool)
+
+		for _, s := range steps {
+			cr := request.Candidates
+			cr.Assume = s.assume
+			cr.Limit = int64(request.Beam)
+
+			cs, err := candidates(channelGraph, cr)
+			if err != nil {
+				return Plan{}, err
+			}
+
+			for _, c := range cs {
+				// already have a channel planned
+				if slices.Contains(s.assume, c.PubKey) {
+					continue
+				}
+
+				n := step{
+					assume: append(append([]lightning.PubKey{}, s.assume...), c.PubKey),
+					chosen: append(append([]RelativeNode{}, s.chosen...), c),
+				}
+
+				if seen[n.key()] {
+					continue
+				}
+				seen[n.key()] = true
+
+				cr.Assume = n.assume
+				nodes, err := relativeNodes(channelGraph, cr)
+				if err != nil {
+					return Plan{}, err
+				}
+				n.meanDistance = meanDistance(nodes)
+
+				next = append(next, n)
+			}
+		}
+
+		// ran out of candidates
+		if len(next) == 0 {
+			break
+		}
+
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].meanDistance < next[j].meanDistance
+		})
+
+		if len(next) > request.Beam {
+			next = next[:request.Beam]
+		}
+
+		steps = next
+	}
+
+	best := steps[0]
+	plan := Plan{
+		Channels:     make([]PlannedChannel, len(best.chosen)),
+		MeanDistance: best.meanDistance,
+	}
+	for i, c := range best.chosen {
+		plan.Channels[i] = PlannedChannel{
+			RelativeNode: c,
+			Amount:       size,
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,225 @@
+package raiju
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_Plan(t *testing.T) {
+	// a linear network with a fork (A) <=> (B) <=> (C) <=> (D) <=> (E) <=> (F) <=> (G)
+	//                                                               \
+	//                                                               (H)
+	l := &lightningerMock{
+		DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+			nodes := make([]lightning.Node, 0)
+			for _, k := range []lightning.PubKey{pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE, pubKeyF, pubKeyG, "H"} {
+				nodes = append(nodes, lightning.Node{
+					PubKey:    k,
+					Alias:     string(k),
+					Updated:   updated,
+					Addresses: []string{clearnetAddress},
+				})
+			}
+
+			return &lightning.Graph{
+				Nodes: nodes,
+				Edges: []lightning.Edge{
+					{Capacity: 1, Node1: pubKeyA, Node2: pubKeyB},
+					{Capacity: 1, Node1: pubKeyB, Node2: pubKeyC},
+					{Capacity: 1, Node1: pubKeyC, Node2: pubKeyD},
+					{Capacity: 1, Node1: pubKeyD, Node2: pubKeyE},
+					{Capacity: 1, Node1: pubKeyE, Node2: pubKeyF},
+					{Capacity: 1, Node1: pubKeyF, Node2: pubKeyG},
+					{Capacity: 1, Node1: pubKeyE, Node2: "H"},
+				},
+			}, nil
+		},
+	}
+
+	candidates := CandidatesRequest{
+		PubKeys:     []lightning.PubKey{pubKeyA},
+		MinCapacity: 1,
+		MinChannels: 1,
+		MinDistance: 2,
+		MinUpdated:  updated.Add(time.Hour * -3),
+		Clearnet:    true,
+	}
+
+	type want struct {
+		pubKeys      []lightning.PubKey
+		amount       lightning.Satoshi
+		meanDistance float64
+	}
+	tests := []struct {
+		name    string
+		request PlanRequest
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "greedy picks the top candidate each step",
+			request: PlanRequest{
+				Candidates:     candidates,
+				Channels:       2,
+				Budget:         2000000,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 5000000,
+				Beam:           1,
+			},
+			want: want{
+				pubKeys:      []lightning.PubKey{pubKeyG, "H"},
+				amount:       1000000,
+				meanDistance: 12.0 / 7.0,
+			},
+			wantErr: false,
+		},
+		{
+			name: "beam search finds a closer plan",
+			request: PlanRequest{
+				Candidates:     candidates,
+				Channels:       2,
+				Budget:         2000000,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 5000000,
+				Beam:           2,
+			},
+			want: want{
+				pubKeys:      []lightning.PubKey{pubKeyG, pubKeyE},
+				amount:       1000000,
+				meanDistance: 11.0 / 7.0,
+			},
+			wantErr: false,
+		},
+		{
+			name: "budget limits number of channels",
+			request: PlanRequest{
+				Candidates:     candidates,
+				Channels:       2,
+				Budget:         1500000,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 5000000,
+				Beam:           1,
+			},
+			want: want{
+				pubKeys:      []lightning.PubKey{pubKeyG},
+				amount:       1500000,
+				meanDistance: 16.0 / 7.0,
+			},
+			wantErr: false,
+		},
+		{
+			name: "budget too small",
+			request: PlanRequest{
+				Candidates:     candidates,
+				Channels:       2,
+				Budget:         500000,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 5000000,
+				Beam:           1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Raiju{
+				l: l,
+			}
+			got, err := r.Plan(context.Background(), tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Raiju.Plan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			pubKeys := make([]lightning.PubKey, len(got.Channels))
+			for i, c := range got.Channels {
+				pubKeys[i] = c.PubKey
+				if c.Amount != tt.want.amount {
+					t.Errorf("Raiju.Plan() amount = %v, want %v", c.Amount, tt.want.amount)
+				}
+			}
+			if !reflect.DeepEqual(pubKeys, tt.want.pubKeys) {
+				t.Errorf("Raiju.Plan() = %v, want %v", pubKeys, tt.want.pubKeys)
+			}
+			if got.MeanDistance != tt.want.meanDistance {
+				t.Errorf("Raiju.Plan() mean distance = %v, want %v", got.MeanDistance, tt.want.meanDistance)
+			}
+		})
+	}
+}
+
+func Test_channelSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      PlanRequest
+		wantChannels int
+		wantSize     lightning.Satoshi
+		wantErr      bool
+	}{
+		{
+			name: "budget split evenly",
+			request: PlanRequest{
+				Channels:       4,
+				Budget:         4000000,
+				MinChannelSize: 500000,
+				MaxChannelSize: 2000000,
+			},
+			wantChannels: 4,
+			wantSize:     1000000,
+			wantErr:      false,
+		},
+		{
+			name: "capped at max size",
+			request: PlanRequest{
+				Channels:       2,
+				Budget:         10000000,
+				MinChannelSize: 500000,
+				MaxChannelSize: 2000000,
+			},
+			wantChannels: 2,
+			wantSize:     2000000,
+			wantErr:      false,
+		},
+		{
+			name: "drop channels to reach min size",
+			request: PlanRequest{
+				Channels:       5,
+				Budget:         2000000,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 2000000,
+			},
+			wantChannels: 2,
+			wantSize:     1000000,
+			wantErr:      false,
+		},
+		{
+			name: "budget too small",
+			request: PlanRequest{
+				Channels:       1,
+				Budget:         100,
+				MinChannelSize: 1000000,
+				MaxChannelSize: 2000000,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels, size, err := channelSize(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("channelSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if channels != tt.wantChannels || size != tt.wantSize {
+				t.Errorf("channelSize() = %v, %v, want %v, %v", channels, size, tt.wantChannels, tt.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package raiju
+
+import (
+	"context"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// probeSteps of the search for the most sats a circular route can carry.
+const probeSteps = 6
+
+// probeAmount finds about the most sats up to max which can make it out the channel and back in through the last hop,
+// zero if not even min can.
+//
+// Probes use payments which can never settle, so they don't cost a thing. Amounts without a route under the max fee
+// count as failed probes.
+func (r Raiju) probeAmount(ctx context.Context, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, min lightning.Satoshi, max lightning.Satoshi, maxFee lightning.FeePPM) (lightning.Satoshi, error) {
+	probe := func(amount lightning.Satoshi) (bool, error) {
+		route, err := r.l.QueryRoute(ctx, amount, outChannelID, lastHopPubKey, maxFee)
+		if err != nil {
+			return false, ctx.Err()
+		}
+
+		p, err := r.l.ProbeRoute(ctx, route)
+		if err != nil {
+			return false, err
+		}
+
+		return p.Reached, nil
+	}
+
+	ok, err := probe(max)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return max, nil
+	}
+
+	// binary search between the most known to pass and the least known to fail
+	var low lightning.Satoshi
+	high := max
+	for i := 0; i < probeSteps && high-low > min; i++ {
+		mid := low + (high-low)/2
+		if mid < min {
+			mid = min
+		}
+
+		ok, err := probe(mid)
+		if err != nil {
+			return low, err
+		}
+		if ok {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	return low, nil
+}
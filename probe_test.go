@@ -0,0 +1,111 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestRaiju_probeAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity lightning.Satoshi
+		noRoute  bool
+		want     lightning.Satoshi
+	}{
+		{
+			name:     "whole amount fits",
+			capacity: 100,
+			want:     50,
+		},
+		{
+			name:     "search down to what fits",
+			capacity: 30,
+			want:     28,
+		},
+		{
+			name:     "not even the min fits",
+			capacity: 4,
+			want:     0,
+		},
+		{
+			name:    "no route",
+			noRoute: true,
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Raiju{
+				l: &lightningerMock{
+					QueryRouteFunc: func(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error) {
+						if tt.noRoute {
+							return lightning.Route{}, errors.New("no route found")
+						}
+						return lightning.Route{Amount: amount.Millis()}, nil
+					},
+					ProbeRouteFunc: func(ctx context.Context, route lightning.Route) (lightning.Probe, error) {
+						return lightning.Probe{Reached: route.Amount <= tt.capacity.Millis()}, nil
+					},
+				},
+			}
+
+			got, err := r.probeAmount(context.Background(), 1, pubKeyB, 5, 50, 500)
+			if err != nil {
+				t.Fatalf("Raiju.probeAmount() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Raiju.probeAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaiju_RebalancePair_probe(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	var amounts []lightning.Satoshi
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				amounts = append(amounts, amount)
+				return lightning.Invoice(""), nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				return lightning.Channel{
+					Edge:         lightning.Edge{Capacity: 1000},
+					ChannelID:    channelID,
+					LocalBalance: 1000,
+					RemoteNode:   lightning.Node{PubKey: pubKeyC},
+				}, nil
+			},
+			QueryRouteFunc: func(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error) {
+				return lightning.Route{Amount: amount.Millis()}, nil
+			},
+			ProbeRouteFunc: func(ctx context.Context, route lightning.Route) (lightning.Probe, error) {
+				return lightning.Probe{Reached: route.Amount <= lightning.Satoshi(30).Millis()}, nil
+			},
+			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	got, err := r.RebalancePair(context.Background(), 1, 2, RebalanceRequest{Amount: 50, Probe: true})
+	if err != nil {
+		t.Fatalf("Raiju.RebalancePair() error = %v", err)
+	}
+	if got.Moved != 50 || len(got.Attempts) != 2 {
+		t.Errorf("Raiju.RebalancePair() = %v in %d attempts, want 50 in 2", got.Moved, len(got.Attempts))
+	}
+
+	// only paying what the probes found can make it around
+	if want := []lightning.Satoshi{28, 22}; !reflect.DeepEqual(amounts, want) {
+		t.Errorf("Raiju.RebalancePair() amounts = %v, want %v", amounts, want)
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
+	"net"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/nyonson/raiju/asn"
 	"github.com/nyonson/raiju/lightning"
 )
 
+// hardcode what distance is considered "distant" for a neighbor
+const distantNeighborLimit int64 = 2
+
 const (
 	// larger step percentages are more efficient because they avoid base fees, but might not get routed
 	maxStepPercent    = 5.0
@@ -18,25 +23,54 @@ const (
 	changeStepPercent = 0.5
 )
 
+// invoiceExpirySlack past the payment timeout before a rebalance invoice expires
+const invoiceExpirySlack = 10 * time.Second
+
+// RebalanceMemo starts the memo of every rebalance invoice, so they can be told apart from real revenue.
+const RebalanceMemo = "raiju rebalance"
+
+// IsRebalanceInvoice is true if the invoice memo marks one of raiju's rebalance invoices.
+func IsRebalanceInvoice(memo string) bool {
+	return strings.HasPrefix(memo, RebalanceMemo)
+}
+
 //go:generate gotests -w -exported raiju.go
-//go:generate moq -stub -skip-ensure -out raiju_mock_test.go . lightninger
+//go:generate moq -stub -skip-ensure -out raiju_mock_test.go . lightninger dialer
 
 type lightninger interface {
-	AddInvoice(ctx context.Context, amount lightning.Satoshi) (lightning.Invoice, error)
+	AddInvoice(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error)
+	BuildRoute(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, hops []lightning.PubKey) (lightning.Route, error)
+	BatchOpenChannel(ctx context.Context, channels []lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error)
+	CancelInvoice(ctx context.Context, invoice lightning.Invoice) error
+	ClosedPeers(ctx context.Context) ([]lightning.PubKey, error)
+	ConnectPeer(ctx context.Context, pubKey lightning.PubKey, address string) error
 	DescribeGraph(ctx context.Context) (*lightning.Graph, error)
 	ForwardingHistory(ctx context.Context, since time.Time) ([]lightning.Forward, error)
 	GetInfo(ctx context.Context) (*lightning.Info, error)
 	GetChannel(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error)
+	GetNode(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error)
 	ListChannels(ctx context.Context) (lightning.Channels, error)
-	SendPayment(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Satoshi, error)
+	OpenChannel(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error)
+	PendingPeers(ctx context.Context) ([]lightning.PubKey, error)
+	ProbeRoute(ctx context.Context, route lightning.Route) (lightning.Probe, error)
+	QueryRoute(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error)
+	SendPayment(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error)
+	SendToRoute(ctx context.Context, invoice lightning.Invoice, route lightning.Route) (lightning.Payment, error)
 	SetFees(ctx context.Context, channelID lightning.ChannelID, fee lightning.FeePPM, maxHTLC lightning.MilliSatoshi) error
+	SettledInvoices(ctx context.Context, since time.Time) ([]lightning.SettledInvoice, error)
 	SubscribeChannelUpdates(ctx context.Context) (<-chan lightning.Channels, <-chan error, error)
 }
 
+// dialer opens network connections, used to probe nodes outside of the lightning node.
+type dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
 // Raiju app.
 type Raiju struct {
 	l lightninger
 	f LiquidityFees
+	d dialer
 }
 
 // New instance of raiju.
@@ -44,17 +78,37 @@ func New(l lightninger, r LiquidityFees) Raiju {
 	return Raiju{
 		l: l,
 		f: r,
+		d: &net.Dialer{Timeout: probeTimeout},
 	}
 }
 
 // RelativeNode has information on a node's graph characteristics relative to other nodes.
 type RelativeNode struct {
 	lightning.Node
-	Distance        int64
-	DistantNeigbors int64
-	Channels        int64
-	Capacity        lightning.Satoshi
-	Neighbors       []lightning.PubKey
+	// Root node the node is closest to
+	Root              lightning.PubKey
+	Distance          int64
+	DistantNeigbors   int64
+	Channels          int64
+	Capacity          lightning.Satoshi
+	Neighbors         []lightning.PubKey
+	Centrality        float64
+	MedianOutboundFee lightning.FeePPM
+	AvgOutboundFee    lightning.FeePPM
+	// MedianInboundFee is the median fee charged by peers to route to the node
+	MedianInboundFee  lightning.FeePPM
+	AvgInboundFee     lightning.FeePPM
+	MedianChannelSize lightning.Satoshi
+	DisabledChannels  int64
+	// OldestChannelAge in blocks
+	OldestChannelAge int64
+	// PeersInASN is the number of the root nodes' peers hosted in the same ASN
+	PeersInASN int64
+	// PeersInSubnet is the number of the root nodes' peers hosted in the same subnet
+	PeersInSubnet int64
+	// Uptime percent observed by probing, only meaningful if Probed
+	Uptime float64
+	Probed bool
 }
 
 // sortDistance sorts nodes by distance, distant neighbors, capacity, and channels
@@ -89,8 +143,8 @@ func (s sortDistance) Len() int {
 
 // CandidatesRequest contains necessary info to perform sorting across the network
 type CandidatesRequest struct {
-	// PubKey is the key of the root node to perform crawl from
-	PubKey lightning.PubKey
+	// PubKeys are the keys of the root nodes to perform crawl from, distance is from the closest root
+	PubKeys []lightning.PubKey
 	// MinCapcity filters nodes with a minimum satoshi capacity (sum of channels)
 	MinCapacity lightning.Satoshi
 	// MinChannels filters nodes with a minimum number of channels
@@ -101,42 +155,114 @@ type CandidatesRequest struct {
 	MinDistantNeighbors int64
 	// MinUpdated filters nodes which have not been updated since time
 	MinUpdated time.Time
-	// Assume channels to these pubkeys
+	// Assume channels from the first root node to these pubkeys
 	Assume []lightning.PubKey
 	// Number of results
 	Limit int64
 	// Filter tor nodes
 	Clearnet bool
+	// Hybrid filters nodes which aren't reachable over both tor and clearnet
+	Hybrid bool
+	// Networks filters nodes without an address on each of these networks
+	Networks []lightning.AddressType
+	// CentralitySamples enables calculating betweenness centrality gain, sampled from this many source nodes
+	CentralitySamples int
+	// Scorer ranks the candidates, defaults to distance
+	Scorer Scorer
+	// Include only nodes on this list, unless it is empty
+	Include NodeList
+	// Exclude nodes on this list, nodes with pending channels to the local node are always excluded
+	Exclude NodeList
+	// ExcludeClosed nodes which the local node had a channel with in the past
+	ExcludeClosed bool
+	// Uptime percent of nodes observed by probing
+	Uptime map[lightning.PubKey]float64
+	// MinUptime filters nodes with an observed uptime percent below this, nodes which have never been probed are kept
+	MinUptime float64
+	// ASNs enables counting the root node's peers in the same ASN as a candidate
+	ASNs *asn.Database
+	// UniqueASN filters nodes in the same ASN as one of the root node's peers, requires ASNs
+	UniqueASN bool
+	// UniqueSubnet filters nodes in the same subnet as one of the root node's peers
+	UniqueSubnet bool
+	// MaxMedianOutboundFee filters nodes with expensive outbound fees, zero is no limit
+	MaxMedianOutboundFee lightning.FeePPM
+	// MaxMedianInboundFee filters nodes which are expensive to route to, zero is no limit
+	MaxMedianInboundFee lightning.FeePPM
+	// MinMedianChannelSize filters nodes with a minimum median channel size
+	MinMedianChannelSize lightning.Satoshi
+	// MinEnabledPercent filters nodes which have disabled too many of their channels
+	MinEnabledPercent float64
+	// MinChannelAge filters nodes without a channel at least this many blocks old
+	MinChannelAge int64
 }
 
 // Candidates walks the lightning network from a specific node keeping track of distance (hops).
 func (r Raiju) Candidates(ctx context.Context, request CandidatesRequest) ([]RelativeNode, error) {
-	// default root node to local if no key supplied
-	if request.PubKey == "" {
+	request, err := r.localize(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// pull entire network graph from lnd
+	channelGraph, err := r.l.DescribeGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates(channelGraph, request)
+}
+
+// localize the request with the local node's state, defaulting the root node and excluding its pending and closed peers.
+func (r Raiju) localize(ctx context.Context, request CandidatesRequest) (CandidatesRequest, error) {
+	// default root node to local if no keys supplied
+	if len(request.PubKeys) == 0 {
 		info, err := r.l.GetInfo(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("unable to get root node info: %w", err)
+			return CandidatesRequest{}, fmt.Errorf("unable to get root node info: %w", err)
 		}
 
-		request.PubKey = info.PubKey
+		request.PubKeys = []lightning.PubKey{info.PubKey}
 	}
 
-	// pull entire network graph from lnd
-	channelGraph, err := r.l.DescribeGraph(ctx)
+	// copy so the caller's list isn't modified
+	request.Exclude.PubKeys = append([]lightning.PubKey{}, request.Exclude.PubKeys...)
+
+	pending, err := r.l.PendingPeers(ctx)
 	if err != nil {
-		return nil, err
+		return CandidatesRequest{}, fmt.Errorf("unable to get pending channels: %w", err)
 	}
+	request.Exclude.PubKeys = append(request.Exclude.PubKeys, pending...)
 
+	if request.ExcludeClosed {
+		closed, err := r.l.ClosedPeers(ctx)
+		if err != nil {
+			return CandidatesRequest{}, fmt.Errorf("unable to get closed channels: %w", err)
+		}
+		request.Exclude.PubKeys = append(request.Exclude.PubKeys, closed...)
+	}
+
+	return request, nil
+}
+
+// relativeNodes of the graph, calculated from the request's root node and assumed channels.
+func relativeNodes(channelGraph *lightning.Graph, request CandidatesRequest) (map[lightning.PubKey]*RelativeNode, error) {
 	// initialize nodes map with static info
 	nodes := make(map[lightning.PubKey]*RelativeNode, len(channelGraph.Nodes))
 
 	for _, n := range channelGraph.Nodes {
+		uptime, probed := request.Uptime[n.PubKey]
 		nodes[n.PubKey] = &RelativeNode{
-			Node: n,
+			Node:   n,
+			Uptime: uptime,
+			Probed: probed,
 		}
 	}
 
-	// calculate node properties based on channels: neighbors, capacity, channels
+	// calculate node properties based on channels: neighbors, capacity, channels, fees, sizes, ages
+	outboundFees := make(map[lightning.PubKey][]lightning.FeePPM)
+	inboundFees := make(map[lightning.PubKey][]lightning.FeePPM)
+	sizes := make(map[lightning.PubKey][]lightning.Satoshi)
 	for _, e := range channelGraph.Edges {
 		if nodes[e.Node1].Neighbors != nil {
 			nodes[e.Node1].Neighbors = append(nodes[e.Node1].Neighbors, e.Node2)
@@ -155,108 +281,196 @@ func (r Raiju) Candidates(ctx context.Context, request CandidatesRequest) ([]Rel
 
 		nodes[e.Node1].Channels++
 		nodes[e.Node2].Channels++
+
+		sizes[e.Node1] = append(sizes[e.Node1], e.Capacity)
+		sizes[e.Node2] = append(sizes[e.Node2], e.Capacity)
+
+		for _, n := range []lightning.PubKey{e.Node1, e.Node2} {
+			if e.FundingHeight > 0 && e.FundingHeight <= channelGraph.Height {
+				age := int64(channelGraph.Height - e.FundingHeight)
+				if age > nodes[n].OldestChannelAge {
+					nodes[n].OldestChannelAge = age
+				}
+			}
+		}
+
+		// a node's outbound fee is its peer's inbound fee, disabled channels aren't routing so their fees don't count
+		if e.Node1Policy != nil {
+			if e.Node1Policy.Disabled {
+				nodes[e.Node1].DisabledChannels++
+			} else {
+				outboundFees[e.Node1] = append(outboundFees[e.Node1], e.Node1Policy.FeeRate)
+				inboundFees[e.Node2] = append(inboundFees[e.Node2], e.Node1Policy.FeeRate)
+			}
+		}
+
+		if e.Node2Policy != nil {
+			if e.Node2Policy.Disabled {
+				nodes[e.Node2].DisabledChannels++
+			} else {
+				outboundFees[e.Node2] = append(outboundFees[e.Node2], e.Node2Policy.FeeRate)
+				inboundFees[e.Node1] = append(inboundFees[e.Node1], e.Node2Policy.FeeRate)
+			}
+		}
+	}
+
+	for k, fees := range outboundFees {
+		nodes[k].MedianOutboundFee = median(fees)
+		nodes[k].AvgOutboundFee = avgFee(fees)
+	}
+
+	for k, fees := range inboundFees {
+		nodes[k].MedianInboundFee = median(fees)
+		nodes[k].AvgInboundFee = avgFee(fees)
 	}
 
-	// Add assumes to root node
+	for k, s := range sizes {
+		nodes[k].MedianChannelSize = median(s)
+	}
+
+	// Add assumes to the first root node
 	for _, c := range request.Assume {
 		if _, ok := nodes[c]; !ok {
-			return []RelativeNode{}, errors.New("candidate node does not exist")
+			return nil, errors.New("candidate node does not exist")
 		}
 
-		if nodes[request.PubKey].Neighbors != nil {
-			nodes[request.PubKey].Neighbors = append(nodes[request.PubKey].Neighbors, c)
+		root := request.PubKeys[0]
+		if nodes[root].Neighbors != nil {
+			nodes[root].Neighbors = append(nodes[root].Neighbors, c)
 		} else {
-			nodes[request.PubKey].Neighbors = []lightning.PubKey{c}
+			nodes[root].Neighbors = []lightning.PubKey{c}
 		}
 
 		if nodes[c].Neighbors != nil {
-			nodes[c].Neighbors = append(nodes[c].Neighbors, request.PubKey)
+			nodes[c].Neighbors = append(nodes[c].Neighbors, root)
 		} else {
-			nodes[c].Neighbors = []lightning.PubKey{request.PubKey}
+			nodes[c].Neighbors = []lightning.PubKey{root}
 		}
 	}
 
-	// BFS node graph to calculate distance from root node
-	var distance int64 = 1
+	// BFS node graph from all roots at once to calculate distance from the closest root
 	visited := make(map[lightning.PubKey]bool)
-
-	// handle strange case where root node doesn't exist for some reason...
-	neighbors := make([]lightning.PubKey, 0)
-	// initialize search from root node's neighbors
-	if n, ok := nodes[request.PubKey]; ok {
-		// root node has no distance to self
-		n.Distance = 0
-		// mark root as visited
-		visited[n.PubKey] = true
-		neighbors = n.Neighbors
+	frontier := make([]lightning.PubKey, 0, len(request.PubKeys))
+	for _, r := range request.PubKeys {
+		// handle strange case where root node doesn't exist for some reason...
+		if n, ok := nodes[r]; ok && !visited[r] {
+			// root node has no distance to self
+			n.Distance = 0
+			n.Root = r
+			visited[r] = true
+			frontier = append(frontier, r)
+		}
 	}
 
-	for len(neighbors) > 0 {
+	for distance := int64(1); len(frontier) > 0; distance++ {
 		next := make([]lightning.PubKey, 0)
-		for _, n := range neighbors {
-			if !visited[n] {
-				nodes[n].Distance = distance
-				visited[n] = true
-
-				for _, neighbor := range nodes[n].Neighbors {
-					if !visited[neighbor] {
-						next = append(next, neighbor)
-					}
+		for _, f := range frontier {
+			for _, neighbor := range nodes[f].Neighbors {
+				if !visited[neighbor] {
+					nodes[neighbor].Distance = distance
+					nodes[neighbor].Root = nodes[f].Root
+					visited[neighbor] = true
+					next = append(next, neighbor)
 				}
 			}
 		}
-		distance++
-		neighbors = next
+		frontier = next
 	}
 
-	unfilteredSpan := make([]RelativeNode, 0)
-	for _, v := range nodes {
-		unfilteredSpan = append(unfilteredSpan, *v)
-	}
-
-	// hardcode what distance is considered "distant" for a neighbor
-	const distantNeighborLimit int64 = 2
-
 	// calculate number of distant neighbors per node
-	for node := range unfilteredSpan {
+	for _, n := range nodes {
 		var count int64
-		for _, neighbor := range unfilteredSpan[node].Neighbors {
+		for _, neighbor := range n.Neighbors {
 			if nodes[neighbor].Distance > distantNeighborLimit {
 				count++
 			}
 		}
 
-		unfilteredSpan[node].DistantNeigbors = count
+		n.DistantNeigbors = count
 	}
 
+	return nodes, nil
+}
+
+// candidates of the graph filtered and ranked by the request.
+func candidates(channelGraph *lightning.Graph, request CandidatesRequest) ([]RelativeNode, error) {
+	nodes, err := relativeNodes(channelGraph, request)
+	if err != nil {
+		return []RelativeNode{}, err
+	}
+
+	// diversity is relative to the roots' peers, so it has to be calculated before filtering
+	all := make([]RelativeNode, 0, len(nodes))
+	for _, n := range nodes {
+		all = append(all, *n)
+	}
+	networkDiversity(nodes, request.PubKeys, all, request.ASNs)
+
 	// filter nodes by request conditions
 	allCandidates := make([]RelativeNode, 0)
-	for _, v := range unfilteredSpan {
-		if v.Capacity >= request.MinCapacity &&
-			v.Channels >= request.MinChannels &&
-			v.Distance >= request.MinDistance &&
-			v.DistantNeigbors >= request.MinDistantNeighbors &&
-			v.Updated.After(request.MinUpdated) {
-			if request.Clearnet {
-				if v.Clearnet() {
-					allCandidates = append(allCandidates, v)
-				}
-			} else {
-				allCandidates = append(allCandidates, v)
-			}
+	for _, v := range all {
+		if len(exclusions(request, v)) == 0 {
+			allCandidates = append(allCandidates, v)
+		}
+	}
+
+	// centrality is expensive to calculate, so only the top candidates by distance are considered
+	if request.CentralitySamples > 0 {
+		allCandidates = rank(allCandidates, DistanceScorer{})
+		if int64(len(allCandidates)) >= request.Limit {
+			allCandidates = allCandidates[:request.Limit]
 		}
+
+		centralityGains(nodes, allCandidates, request.CentralitySamples)
 	}
 
-	sort.Sort(sort.Reverse(sortDistance(allCandidates)))
+	scorer := request.Scorer
+	if scorer == nil {
+		scorer = DistanceScorer{}
+	}
 
-	candidates := allCandidates
-	if int64(len(allCandidates)) >= request.Limit {
-		candidates = allCandidates[:request.Limit]
+	candidates := rank(allCandidates, scorer)
+	if int64(len(candidates)) >= request.Limit {
+		candidates = candidates[:request.Limit]
 	}
 
 	return candidates, nil
 }
 
+// avgFee of the fees, zero if there are none.
+func avgFee(fees []lightning.FeePPM) lightning.FeePPM {
+	if len(fees) == 0 {
+		return 0
+	}
+
+	var total lightning.FeePPM
+	for _, f := range fees {
+		total += f
+	}
+
+	return total / lightning.FeePPM(len(fees))
+}
+
+// median of the values, zero if there are none.
+func median[T ~int64 | ~float64](values []T) T {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]T, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
 // Fees to encourage a balanced channel.
 //
 // Fees are initially set across all channels and then continuously updated as channel liquidity changes.
@@ -302,131 +516,512 @@ func (r Raiju) Fees(ctx context.Context) (chan map[lightning.ChannelID]lightning
 	return updates, errors, nil
 }
 
+// PreviewFees which would be set on channels based on their current liquidity, without setting them.
+func (r Raiju) PreviewFees(ctx context.Context) (map[lightning.ChannelID]lightning.FeePPM, error) {
+	channels, err := r.l.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.feeChanges(channels), nil
+}
+
+// ApplyFees once to channels based on their current liquidity, return updated channels and their new fees.
+func (r Raiju) ApplyFees(ctx context.Context) (map[lightning.ChannelID]lightning.FeePPM, error) {
+	channels, err := r.l.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.setFees(ctx, channels)
+}
+
+// feeChanges for channels who's fee doesn't match their liquidity.
+func (r Raiju) feeChanges(channels lightning.Channels) map[lightning.ChannelID]lightning.FeePPM {
+	changes := map[lightning.ChannelID]lightning.FeePPM{}
+	for _, c := range channels {
+		fee := r.f.Fee(c)
+		if c.LocalFee != fee && !c.Private {
+			changes[c.ChannelID] = fee
+		}
+	}
+
+	return changes
+}
+
 // setFees on channels who's liquidity has changed, return updated channels and their new liquidity level.
 func (r Raiju) setFees(ctx context.Context, channels lightning.Channels) (map[lightning.ChannelID]lightning.FeePPM, error) {
+	changes := r.feeChanges(channels)
 	updates := map[lightning.ChannelID]lightning.FeePPM{}
 	// update channel fees based on liquidity, but only change if necessary
 	for _, c := range channels {
-		fee := r.f.Fee(c)
+		fee, ok := changes[c.ChannelID]
+		if !ok {
+			continue
+		}
 		// flow control, broadcast to the network the max payment size to forward through this channel.
 		maxPayment := c.LocalBalance.Millis() / 2
-		if c.LocalFee != fee && !c.Private {
-			err := r.l.SetFees(ctx, c.ChannelID, fee, maxPayment)
-			if err != nil {
-				return map[lightning.ChannelID]lightning.FeePPM{}, err
-			}
-			updates[c.ChannelID] = fee
+		err := r.l.SetFees(ctx, c.ChannelID, fee, maxPayment)
+		if err != nil {
+			return map[lightning.ChannelID]lightning.FeePPM{}, err
 		}
+		updates[c.ChannelID] = fee
 	}
 
 	return updates, nil
 }
 
-// Rebalance liquidity out of outChannelID and in through lastHopPubkey and returns the percent of capacity rebalanced.
+// RebalanceRequest options for moving liquidity between channels.
+type RebalanceRequest struct {
+	// MaxPercent of an out channel's capacity to move
+	MaxPercent float64
+	// Amount to move between a pair of channels, overrides MaxPercent when set
+	Amount lightning.Satoshi
+	// MaxFee willing to pay, zero defaults to the liquidity fees' rebalance fee
+	MaxFee lightning.FeePPM
+	// MaxParts a payment can be split into, zero or one sends single part payments
+	MaxParts uint32
+	// MaxAttempts of payments per channel pair, zero is no limit
+	MaxAttempts int
+	// Timeout of the whole rebalance, zero is no limit
+	Timeout time.Duration
+	// Strategy to order the channel pairs tried, empty defaults to the value strategy
+	Strategy PairStrategy
+	// History of past attempts to estimate the value of channel pairs and fees spent today
+	History RebalanceHistory
+	// Concurrency of channel pairs rebalanced at once, zero or one rebalances one pair at a time
+	Concurrency int
+	// FeeBudget of sats to spend on fees in a run, zero is no limit
+	FeeBudget lightning.Satoshi
+	// DailyFeeBudget of sats to spend on fees in the last day including earlier runs, zero is no limit
+	DailyFeeBudget lightning.Satoshi
+	// Probe routes with payments which can't settle to size single part payments before paying
+	Probe bool
+	// Routes of single part payments are found by raiju over the graph instead of left up to lnd
+	Routes bool
+	// Backoff of channels and pairs after a run without moving any sats, doubling with every such run in a row, zero
+	// never backs off
+	Backoff time.Duration
+}
+
+// RebalanceResult of rebalancing a pair of channels.
+type RebalanceResult struct {
+	Out   lightning.ChannelID
+	In    lightning.ChannelID
+	Moved lightning.Satoshi
+	Fees  lightning.Satoshi
+	// Attempts of payments, failed or not
+	Attempts []RebalanceAttempt
+	Duration time.Duration
+}
+
+// EffectivePPM of the fees paid for the sats moved, zero if nothing moved.
+func (r RebalanceResult) EffectivePPM() lightning.FeePPM {
+	if r.Moved == 0 {
+		return 0
+	}
+
+	return lightning.FeePPM(effectivePPM(r.Fees, r.Moved))
+}
+
+// RebalanceResults of a run, one per pair of channels attempted.
+type RebalanceResults []RebalanceResult
+
+// Attempts of every pair in the run.
+func (rs RebalanceResults) Attempts() []RebalanceAttempt {
+	var attempts []RebalanceAttempt
+	for _, r := range rs {
+		attempts = append(attempts, r.Attempts...)
+	}
+
+	return attempts
+}
+
+// Total of the run's results without a pair, the duration is summed so can be longer than the run if pairs ran at once.
+func (rs RebalanceResults) Total() RebalanceResult {
+	var total RebalanceResult
+	for _, r := range rs {
+		total.Moved += r.Moved
+		total.Fees += r.Fees
+		total.Attempts = append(total.Attempts, r.Attempts...)
+		total.Duration += r.Duration
+	}
+
+	return total
+}
+
+// routesGraph to find circular paths over if the request wants them, described once per rebalance, nil otherwise.
+func (r Raiju) routesGraph(ctx context.Context, request RebalanceRequest) (*lightning.Graph, error) {
+	if !request.Routes || request.multiPart() {
+		return nil, nil
+	}
+
+	graph, err := r.l.DescribeGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe graph: %w", err)
+	}
+
+	return graph, nil
+}
+
+// multiPart is true if payments can be split.
+func (r RebalanceRequest) multiPart() bool {
+	return r.MaxParts > 1
+}
+
+// firstStep amount to try and move at once out of a channel with capacity.
 //
-// The amount of sats rebalanced is based on the capacity of the out channel. Each rebalance attempt will try to move
-// stepPercent worth of sats. A maximum of maxPercent of sats will be moved. The maxFee in ppm controls the amount
-// willing to pay for rebalance.
-func (r Raiju) rebalanceChannel(ctx context.Context, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, stepPercent float64, maxPercent float64, maxFee lightning.FeePPM) (float64, lightning.Satoshi, error) {
-	// calculate invoice value
+// Multi-part payments go for everything up front since lnd splits it across the routes it finds.
+func (r RebalanceRequest) firstStep(capacity lightning.Satoshi, remaining lightning.Satoshi) lightning.Satoshi {
+	if max := percentOf(capacity, maxStepPercent); !r.multiPart() && remaining > max {
+		return max
+	}
+
+	return remaining
+}
+
+// invoiceExpiry of a rebalance invoice, long enough to pay it along every route tried.
+//
+// The slack keeps an HTLC sent at the very end of the timeout from reaching an already expired invoice, which would
+// look like a routing failure.
+func invoiceExpiry(routes bool) time.Duration {
+	if routes {
+		return routeCandidates*lightning.PaymentTimeout + invoiceExpirySlack
+	}
+
+	return lightning.PaymentTimeout + invoiceExpirySlack
+}
+
+// percentOf the capacity in sats.
+func percentOf(capacity lightning.Satoshi, percent float64) lightning.Satoshi {
+	return lightning.Satoshi(float64(capacity) * percent / 100)
+}
+
+// rebalanceChannel liquidity out of outChannelID and in through lastHopPubkey and returns the sats moved.
+//
+// Each rebalance attempt will try to move step sats, shrinking the step on failure. A maximum of max sats will be
+// moved. The request's max fee in ppm controls the amount willing to pay for rebalance. Every payment attempted is
+// returned, failed or not. Payments stop once the fee budget is spent. If a graph is given, payments are sent along
+// circular paths through it. Each payment gets its own short lived invoice, which is canceled if the payment fails.
+func (r Raiju) rebalanceChannel(ctx context.Context, outChannelID lightning.ChannelID, inChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, step lightning.Satoshi, max lightning.Satoshi, request RebalanceRequest, budget *feeBudget, graph *lightning.Graph) (lightning.Satoshi, lightning.Satoshi, []RebalanceAttempt, error) {
 	c, err := r.l.GetChannel(ctx, outChannelID)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, nil, err
 	}
 
-	amount := int64(float64(c.Capacity) * stepPercent / 100)
+	// small targeted amounts still get a try
+	minStep := percentOf(c.Capacity, minStepPercent)
+	if minStep > max {
+		minStep = max
+	}
+	changeStep := percentOf(c.Capacity, changeStepPercent)
+	memo := fmt.Sprintf("%s %d:%d", RebalanceMemo, outChannelID, inChannelID)
 
-	var currentStepPercent = stepPercent
-	var percentRebalanced float64
+	var moved lightning.Satoshi
 	var totalFeePaid lightning.Satoshi
+	var attempts []RebalanceAttempt
+
+	for moved < max && step >= minStep && step > 0 {
+		if err := ctx.Err(); err != nil {
+			return moved, totalFeePaid, attempts, err
+		}
+		if request.MaxAttempts > 0 && len(attempts) >= request.MaxAttempts {
+			break
+		}
+
+		amount := step
+		if remaining := max - moved; amount > remaining {
+			amount = remaining
+		}
+
+		// find out how much can make it around before paying for it
+		if request.Probe && !request.multiPart() {
+			probed, err := r.probeAmount(ctx, outChannelID, lastHopPubKey, minStep, amount, request.MaxFee)
+			if err != nil {
+				return moved, totalFeePaid, attempts, fmt.Errorf("unable to probe circular route: %w", err)
+			}
+			if probed == 0 {
+				break
+			}
+			amount = probed
+			step = probed
+		}
+
+		amount, reserved := budget.reserve(amount, request.MaxFee)
+		if amount < minStep || amount == 0 {
+			budget.settle(reserved, 0)
+			break
+		}
 
-	for percentRebalanced < maxPercent && currentStepPercent >= minStepPercent {
 		// create and pay invoice
-		invoice, err := r.l.AddInvoice(ctx, lightning.Satoshi(amount))
+		invoice, err := r.l.AddInvoice(ctx, amount, memo, invoiceExpiry(graph != nil))
 		if err != nil {
-			return 0, 0, fmt.Errorf("error creating circular rebalance invoice: %w", err)
+			budget.settle(reserved, 0)
+			return moved, totalFeePaid, attempts, fmt.Errorf("error creating circular rebalance invoice: %w", err)
+		}
+		attempt := RebalanceAttempt{
+			Time:   time.Now(),
+			Out:    outChannelID,
+			In:     inChannelID,
+			Amount: amount,
+		}
+		var payment lightning.Payment
+		if graph != nil {
+			payment, err = r.payCircular(ctx, graph, invoice, c, lastHopPubKey, amount, request.MaxFee)
+		} else {
+			payment, err = r.l.SendPayment(ctx, invoice, outChannelID, lastHopPubKey, request.MaxFee, request.MaxParts)
 		}
-		feePaid, err := r.l.SendPayment(ctx, invoice, outChannelID, lastHopPubKey, maxFee)
-		// assume payment failures might work if we lower the step percentage
+		attempt.Route = payment.Route
+		attempt.FailureCode = payment.FailureCode
+		attempt.FailureSource = payment.FailureSource
+		// assume payment failures might work if we lower the step
 		// less efficient rebalances, but could still work
 		if err != nil {
-			currentStepPercent = currentStepPercent - changeStepPercent
+			budget.settle(reserved, 0)
+			attempt.Error = err.Error()
+			// a canceled run still cleans up after itself
+			if cerr := r.l.CancelInvoice(context.WithoutCancel(ctx), invoice); cerr != nil {
+				attempt.Error = fmt.Sprintf("%s, %s", attempt.Error, cerr)
+			}
+			attempts = append(attempts, attempt)
+			// multi-part steps start large, so back off faster
+			if request.multiPart() {
+				step = step / 2
+			} else {
+				step = step - changeStep
+			}
 			continue
 		}
-		percentRebalanced += currentStepPercent
-		totalFeePaid += feePaid
+		budget.settle(reserved, payment.Fee)
+		attempt.Succeeded = true
+		attempt.Fee = payment.Fee
+		attempts = append(attempts, attempt)
+		moved += amount
+		totalFeePaid += payment.Fee
+	}
+
+	return moved, totalFeePaid, attempts, nil
+}
+
+// RebalancePair pushes the request's amount, or max percent of the out channel's capacity, into the in channel.
+func (r Raiju) RebalancePair(ctx context.Context, outChannelID lightning.ChannelID, inChannelID lightning.ChannelID, request RebalanceRequest) (RebalanceResult, error) {
+	start := time.Now()
+	result := RebalanceResult{Out: outChannelID, In: inChannelID}
+
+	if outChannelID == inChannelID {
+		return result, errors.New("unable to rebalance a channel into itself")
+	}
+
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+		defer cancel()
+	}
+
+	out, err := r.l.GetChannel(ctx, outChannelID)
+	if err != nil {
+		return result, fmt.Errorf("unable to get channel %d: %w", outChannelID, err)
+	}
+
+	in, err := r.l.GetChannel(ctx, inChannelID)
+	if err != nil {
+		return result, fmt.Errorf("unable to get channel %d: %w", inChannelID, err)
+	}
+
+	if request.MaxFee == 0 {
+		request.MaxFee = r.f.RebalanceFee()
 	}
 
-	return percentRebalanced, totalFeePaid, nil
+	max := request.Amount
+	if max == 0 {
+		max = percentOf(out.Capacity, request.MaxPercent)
+	}
+	if max > out.LocalBalance {
+		return result, fmt.Errorf("unable to move %d sats out of channel %d with %d local sats", max, outChannelID, out.LocalBalance)
+	}
+
+	graph, err := r.routesGraph(ctx, request)
+	if err != nil {
+		return result, err
+	}
+
+	result.Moved, result.Fees, result.Attempts, err = r.rebalanceChannel(ctx, outChannelID, inChannelID, in.RemoteNode.PubKey, request.firstStep(out.Capacity, max), max, request, nil, graph)
+	result.Duration = time.Since(start)
+
+	return result, err
 }
 
-// Rebalance high local liquidity channels into low liquidity channels, return percent rebalanced per channel attempted.
-func (r Raiju) Rebalance(ctx context.Context, maxPercent float64, maxFee lightning.FeePPM) (map[lightning.ChannelID]float64, error) {
+// Rebalance high local liquidity channels into low liquidity channels, returning the result of every pair attempted.
+//
+// Up to the request's concurrency of channel pairs are rebalanced at once, but a channel is only ever in one pair at a
+// time. Fee budgets are shared by all pairs. Canceling the context stops all pairs and returns what was done so far.
+func (r Raiju) Rebalance(ctx context.Context, request RebalanceRequest) (RebalanceResults, error) {
+	if request.MaxFee == 0 {
+		request.MaxFee = r.f.RebalanceFee()
+	}
+
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+		defer cancel()
+	}
+
+	// stop the other pairs on the first error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	local, err := r.l.GetInfo(ctx)
 	if err != nil {
-		return map[lightning.ChannelID]float64{}, err
+		return nil, err
 	}
 
 	channels, err := r.l.ListChannels(ctx)
 	if err != nil {
-		return map[lightning.ChannelID]float64{}, err
+		return nil, err
 	}
 
 	hlcs, llcs := r.f.RebalanceChannels(channels)
+	pending := r.pairs(hlcs, llcs, request)
+
+	// skip the pairs which keep failing for a while
+	if request.Backoff > 0 {
+		now := time.Now()
+		ready := pending[:0]
+		for _, p := range pending {
+			if !request.History.coolingDown(p.out.ChannelID, p.in.ChannelID, now, request.Backoff) {
+				ready = append(ready, p)
+			}
+		}
+		pending = ready
+	}
 
-	// Shuffle arrays so different combos are tried
-	rand.Shuffle(len(hlcs), func(i, j int) {
-		hlcs[i], hlcs[j] = hlcs[j], hlcs[i]
-	})
+	graph, err := r.routesGraph(ctx, request)
+	if err != nil {
+		return nil, err
+	}
 
-	var totalFeePaid lightning.Satoshi
-	rebalanced := map[lightning.ChannelID]float64{}
-
-	// Roll through high liquidity channels and try to push things through the low liquidity ones.
-	for _, h := range hlcs {
-		percentRebalanced := float64(0)
-
-		// reshuffle low liquidity channels each time
-		rand.Shuffle(len(llcs), func(i, j int) {
-			llcs[i], llcs[j] = llcs[j], llcs[i]
-		})
-		for _, l := range llcs {
-			// the largest step amount needs to be less than the remaining percent to rebalance
-			pl := (maxPercent - percentRebalanced)
-			ms := maxStepPercent
-			if pl < ms {
-				ms = pl
-			}
+	budget := newFeeBudget(request.FeeBudget, request.DailyFeeBudget, request.History.FeesSince(time.Now().Add(-24*time.Hour)))
+	concurrency := request.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-			// get the non-local node of the channel
-			lastHopPubkey := l.Node1
-			if lastHopPubkey == local.PubKey {
-				lastHopPubkey = l.Node2
-			}
+	type done struct {
+		result RebalanceResult
+		err    error
+	}
+	dones := make(chan done)
+	running := 0
+	busy := map[lightning.ChannelID]bool{}
 
-			// Check that the channel would still be low liquidity to avoid the risk of paying a high fee
-			// to rebalance and then a standard payment cancels out the liquidity
-			ul, err := r.l.GetChannel(ctx, l.ChannelID)
-			if err != nil {
-				return map[lightning.ChannelID]float64{}, err
-			}
+	var results RebalanceResults
+	var firstErr error
+	moved := map[lightning.ChannelID]lightning.Satoshi{}
 
-			potentialLocal := lightning.Satoshi(float64(h.Capacity) * maxPercent)
-			// only shift liquidity if the fees won't change
-			if r.f.PotentialFee(ul, potentialLocal) != r.f.Fee(ul) {
-				p, f, err := r.rebalanceChannel(ctx, h.ChannelID, lastHopPubkey, ms, pl, r.f.RebalanceFee())
-				if err != nil {
-					return map[lightning.ChannelID]float64{}, err
+	for {
+		if err := ctx.Err(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		// start the most wanted pairs whose channels are free
+		for firstErr == nil && running < concurrency && !budget.spent() {
+			next := -1
+			for i, p := range pending {
+				if !busy[p.out.ChannelID] && !busy[p.in.ChannelID] {
+					next = i
+					break
 				}
+			}
+			if next == -1 {
+				break
+			}
+			p := pending[next]
+			pending = append(pending[:next], pending[next+1:]...)
 
-				percentRebalanced += p
-				totalFeePaid += f
+			// the largest step amount needs to be less than the remaining amount to rebalance
+			remaining := percentOf(p.out.Capacity, request.MaxPercent) - moved[p.out.ChannelID]
+			if remaining <= 0 {
+				continue
 			}
+
+			busy[p.out.ChannelID] = true
+			busy[p.in.ChannelID] = true
+			running++
+			go func(p rebalancePair, remaining lightning.Satoshi) {
+				result, err := r.tryPair(ctx, local.PubKey, p, remaining, request, budget, graph)
+				dones <- done{result: result, err: err}
+			}(p, remaining)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		d := <-dones
+		running--
+		busy[d.result.Out] = false
+		busy[d.result.In] = false
+		moved[d.result.Out] += d.result.Moved
+		if len(d.result.Attempts) > 0 {
+			results = append(results, d.result)
+		}
+		if d.err != nil && firstErr == nil {
+			firstErr = d.err
+			cancel()
+		}
+	}
+
+	return results, firstErr
+}
+
+// tryPair rebalances up to remaining sats out of the pair's high liquidity channel into its low liquidity one.
+func (r Raiju) tryPair(ctx context.Context, local lightning.PubKey, p rebalancePair, remaining lightning.Satoshi, request RebalanceRequest, budget *feeBudget, graph *lightning.Graph) (RebalanceResult, error) {
+	start := time.Now()
+	h, l := p.out, p.in
+	result := RebalanceResult{Out: h.ChannelID, In: l.ChannelID}
+
+	// get the non-local node of the channel
+	lastHopPubkey := l.Node1
+	if lastHopPubkey == local {
+		lastHopPubkey = l.Node2
+	}
+
+	// Check that the channel would still be low liquidity to avoid the risk of paying a high fee
+	// to rebalance and then a standard payment cancels out the liquidity
+	ul, err := r.l.GetChannel(ctx, l.ChannelID)
+	if err != nil {
+		return result, err
+	}
+
+	// only shift liquidity if the fees won't change
+	potentialLocal := percentOf(h.Capacity, request.MaxPercent)
+	if r.f.PotentialFee(ul, potentialLocal) == r.f.Fee(ul) {
+		return result, nil
+	}
+
+	result.Moved, result.Fees, result.Attempts, err = r.rebalanceChannel(ctx, h.ChannelID, l.ChannelID, lastHopPubkey, request.firstStep(h.Capacity, remaining), remaining, request, budget, graph)
+	result.Duration = time.Since(start)
+
+	return result, err
+}
+
+// Invoices settled since the time given, newest first.
+//
+// Rebalance invoices are the node paying itself, so they are left out unless asked for.
+func (r Raiju) Invoices(ctx context.Context, since time.Time, rebalances bool) ([]lightning.SettledInvoice, error) {
+	settled, err := r.l.SettledInvoices(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make([]lightning.SettledInvoice, 0, len(settled))
+	for _, i := range settled {
+		if rebalances || !IsRebalanceInvoice(i.Memo) {
+			invoices = append(invoices, i)
 		}
-		rebalanced[h.ChannelID] = percentRebalanced
 	}
 
-	return rebalanced, nil
+	return invoices, nil
 }
 
 // Reaper calculates inefficient channels which should be closed.
@@ -6,6 +6,7 @@ package raiju
 import (
 	"context"
 	"github.com/nyonson/raiju/lightning"
+	"net"
 	"sync"
 	"time"
 )
@@ -16,9 +17,24 @@ import (
 //
 //		// make and configure a mocked lightninger
 //		mockedlightninger := &lightningerMock{
-//			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi) (lightning.Invoice, error) {
+//			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
 //				panic("mock out the AddInvoice method")
 //			},
+//			BatchOpenChannelFunc: func(ctx context.Context, channels []lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+//				panic("mock out the BatchOpenChannel method")
+//			},
+//			BuildRouteFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, hops []lightning.PubKey) (lightning.Route, error) {
+//				panic("mock out the BuildRoute method")
+//			},
+//			CancelInvoiceFunc: func(ctx context.Context, invoice lightning.Invoice) error {
+//				panic("mock out the CancelInvoice method")
+//			},
+//			ClosedPeersFunc: func(ctx context.Context) ([]lightning.PubKey, error) {
+//				panic("mock out the ClosedPeers method")
+//			},
+//			ConnectPeerFunc: func(ctx context.Context, pubKey lightning.PubKey, address string) error {
+//				panic("mock out the ConnectPeer method")
+//			},
 //			DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
 //				panic("mock out the DescribeGraph method")
 //			},
@@ -31,15 +47,36 @@ import (
 //			GetInfoFunc: func(ctx context.Context) (*lightning.Info, error) {
 //				panic("mock out the GetInfo method")
 //			},
+//			GetNodeFunc: func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+//				panic("mock out the GetNode method")
+//			},
 //			ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
 //				panic("mock out the ListChannels method")
 //			},
-//			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Satoshi, error) {
+//			OpenChannelFunc: func(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+//				panic("mock out the OpenChannel method")
+//			},
+//			PendingPeersFunc: func(ctx context.Context) ([]lightning.PubKey, error) {
+//				panic("mock out the PendingPeers method")
+//			},
+//			ProbeRouteFunc: func(ctx context.Context, route lightning.Route) (lightning.Probe, error) {
+//				panic("mock out the ProbeRoute method")
+//			},
+//			QueryRouteFunc: func(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error) {
+//				panic("mock out the QueryRoute method")
+//			},
+//			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
 //				panic("mock out the SendPayment method")
 //			},
+//			SendToRouteFunc: func(ctx context.Context, invoice lightning.Invoice, route lightning.Route) (lightning.Payment, error) {
+//				panic("mock out the SendToRoute method")
+//			},
 //			SetFeesFunc: func(ctx context.Context, channelID lightning.ChannelID, fee lightning.FeePPM, maxHTLC lightning.MilliSatoshi) error {
 //				panic("mock out the SetFees method")
 //			},
+//			SettledInvoicesFunc: func(ctx context.Context, since time.Time) ([]lightning.SettledInvoice, error) {
+//				panic("mock out the SettledInvoices method")
+//			},
 //			SubscribeChannelUpdatesFunc: func(ctx context.Context) (<-chan lightning.Channels, <-chan error, error) {
 //				panic("mock out the SubscribeChannelUpdates method")
 //			},
@@ -51,7 +88,22 @@ import (
 //	}
 type lightningerMock struct {
 	// AddInvoiceFunc mocks the AddInvoice method.
-	AddInvoiceFunc func(ctx context.Context, amount lightning.Satoshi) (lightning.Invoice, error)
+	AddInvoiceFunc func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error)
+
+	// BatchOpenChannelFunc mocks the BatchOpenChannel method.
+	BatchOpenChannelFunc func(ctx context.Context, channels []lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error)
+
+	// BuildRouteFunc mocks the BuildRoute method.
+	BuildRouteFunc func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, hops []lightning.PubKey) (lightning.Route, error)
+
+	// CancelInvoiceFunc mocks the CancelInvoice method.
+	CancelInvoiceFunc func(ctx context.Context, invoice lightning.Invoice) error
+
+	// ClosedPeersFunc mocks the ClosedPeers method.
+	ClosedPeersFunc func(ctx context.Context) ([]lightning.PubKey, error)
+
+	// ConnectPeerFunc mocks the ConnectPeer method.
+	ConnectPeerFunc func(ctx context.Context, pubKey lightning.PubKey, address string) error
 
 	// DescribeGraphFunc mocks the DescribeGraph method.
 	DescribeGraphFunc func(ctx context.Context) (*lightning.Graph, error)
@@ -65,15 +117,36 @@ type lightningerMock struct {
 	// GetInfoFunc mocks the GetInfo method.
 	GetInfoFunc func(ctx context.Context) (*lightning.Info, error)
 
+	// GetNodeFunc mocks the GetNode method.
+	GetNodeFunc func(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error)
+
 	// ListChannelsFunc mocks the ListChannels method.
 	ListChannelsFunc func(ctx context.Context) (lightning.Channels, error)
 
+	// OpenChannelFunc mocks the OpenChannel method.
+	OpenChannelFunc func(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error)
+
+	// PendingPeersFunc mocks the PendingPeers method.
+	PendingPeersFunc func(ctx context.Context) ([]lightning.PubKey, error)
+
+	// ProbeRouteFunc mocks the ProbeRoute method.
+	ProbeRouteFunc func(ctx context.Context, route lightning.Route) (lightning.Probe, error)
+
+	// QueryRouteFunc mocks the QueryRoute method.
+	QueryRouteFunc func(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error)
+
 	// SendPaymentFunc mocks the SendPayment method.
-	SendPaymentFunc func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Satoshi, error)
+	SendPaymentFunc func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error)
+
+	// SendToRouteFunc mocks the SendToRoute method.
+	SendToRouteFunc func(ctx context.Context, invoice lightning.Invoice, route lightning.Route) (lightning.Payment, error)
 
 	// SetFeesFunc mocks the SetFees method.
 	SetFeesFunc func(ctx context.Context, channelID lightning.ChannelID, fee lightning.FeePPM, maxHTLC lightning.MilliSatoshi) error
 
+	// SettledInvoicesFunc mocks the SettledInvoices method.
+	SettledInvoicesFunc func(ctx context.Context, since time.Time) ([]lightning.SettledInvoice, error)
+
 	// SubscribeChannelUpdatesFunc mocks the SubscribeChannelUpdates method.
 	SubscribeChannelUpdatesFunc func(ctx context.Context) (<-chan lightning.Channels, <-chan error, error)
 
@@ -85,6 +158,51 @@ type lightningerMock struct {
 			Ctx context.Context
 			// Amount is the amount argument value.
 			Amount lightning.Satoshi
+			// Memo is the memo argument value.
+			Memo string
+			// Expiry is the expiry argument value.
+			Expiry time.Duration
+		}
+		// BatchOpenChannel holds details about calls to the BatchOpenChannel method.
+		BatchOpenChannel []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Channels is the channels argument value.
+			Channels []lightning.NewChannel
+			// FeeRate is the feeRate argument value.
+			FeeRate lightning.SatPerVByte
+		}
+		// BuildRoute holds details about calls to the BuildRoute method.
+		BuildRoute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Invoice is the invoice argument value.
+			Invoice lightning.Invoice
+			// OutChannelID is the outChannelID argument value.
+			OutChannelID lightning.ChannelID
+			// Hops is the hops argument value.
+			Hops []lightning.PubKey
+		}
+		// CancelInvoice holds details about calls to the CancelInvoice method.
+		CancelInvoice []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Invoice is the invoice argument value.
+			Invoice lightning.Invoice
+		}
+		// ClosedPeers holds details about calls to the ClosedPeers method.
+		ClosedPeers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ConnectPeer holds details about calls to the ConnectPeer method.
+		ConnectPeer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PubKey is the pubKey argument value.
+			PubKey lightning.PubKey
+			// Address is the address argument value.
+			Address string
 		}
 		// DescribeGraph holds details about calls to the DescribeGraph method.
 		DescribeGraph []struct {
@@ -110,11 +228,52 @@ type lightningerMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// GetNode holds details about calls to the GetNode method.
+		GetNode []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PubKey is the pubKey argument value.
+			PubKey lightning.PubKey
+		}
 		// ListChannels holds details about calls to the ListChannels method.
 		ListChannels []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// OpenChannel holds details about calls to the OpenChannel method.
+		OpenChannel []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Channel is the channel argument value.
+			Channel lightning.NewChannel
+			// FeeRate is the feeRate argument value.
+			FeeRate lightning.SatPerVByte
+		}
+		// PendingPeers holds details about calls to the PendingPeers method.
+		PendingPeers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ProbeRoute holds details about calls to the ProbeRoute method.
+		ProbeRoute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Route is the route argument value.
+			Route lightning.Route
+		}
+		// QueryRoute holds details about calls to the QueryRoute method.
+		QueryRoute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Amount is the amount argument value.
+			Amount lightning.Satoshi
+			// OutChannelID is the outChannelID argument value.
+			OutChannelID lightning.ChannelID
+			// LastHopPubKey is the lastHopPubKey argument value.
+			LastHopPubKey lightning.PubKey
+			// MaxFee is the maxFee argument value.
+			MaxFee lightning.FeePPM
+		}
 		// SendPayment holds details about calls to the SendPayment method.
 		SendPayment []struct {
 			// Ctx is the ctx argument value.
@@ -127,6 +286,17 @@ type lightningerMock struct {
 			LastHopPubKey lightning.PubKey
 			// MaxFee is the maxFee argument value.
 			MaxFee lightning.FeePPM
+			// MaxParts is the maxParts argument value.
+			MaxParts uint32
+		}
+		// SendToRoute holds details about calls to the SendToRoute method.
+		SendToRoute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Invoice is the invoice argument value.
+			Invoice lightning.Invoice
+			// Route is the route argument value.
+			Route lightning.Route
 		}
 		// SetFees holds details about calls to the SetFees method.
 		SetFees []struct {
@@ -139,6 +309,13 @@ type lightningerMock struct {
 			// MaxHTLC is the maxHTLC argument value.
 			MaxHTLC lightning.MilliSatoshi
 		}
+		// SettledInvoices holds details about calls to the SettledInvoices method.
+		SettledInvoices []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Since is the since argument value.
+			Since time.Time
+		}
 		// SubscribeChannelUpdates holds details about calls to the SubscribeChannelUpdates method.
 		SubscribeChannelUpdates []struct {
 			// Ctx is the ctx argument value.
@@ -146,24 +323,40 @@ type lightningerMock struct {
 		}
 	}
 	lockAddInvoice              sync.RWMutex
+	lockBatchOpenChannel        sync.RWMutex
+	lockBuildRoute              sync.RWMutex
+	lockCancelInvoice           sync.RWMutex
+	lockClosedPeers             sync.RWMutex
+	lockConnectPeer             sync.RWMutex
 	lockDescribeGraph           sync.RWMutex
 	lockForwardingHistory       sync.RWMutex
 	lockGetChannel              sync.RWMutex
 	lockGetInfo                 sync.RWMutex
+	lockGetNode                 sync.RWMutex
 	lockListChannels            sync.RWMutex
+	lockOpenChannel             sync.RWMutex
+	lockPendingPeers            sync.RWMutex
+	lockProbeRoute              sync.RWMutex
+	lockQueryRoute              sync.RWMutex
 	lockSendPayment             sync.RWMutex
+	lockSendToRoute             sync.RWMutex
 	lockSetFees                 sync.RWMutex
+	lockSettledInvoices         sync.RWMutex
 	lockSubscribeChannelUpdates sync.RWMutex
 }
 
 // AddInvoice calls AddInvoiceFunc.
-func (mock *lightningerMock) AddInvoice(ctx context.Context, amount lightning.Satoshi) (lightning.Invoice, error) {
+func (mock *lightningerMock) AddInvoice(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
 	callInfo := struct {
 		Ctx    context.Context
 		Amount lightning.Satoshi
+		Memo   string
+		Expiry time.Duration
 	}{
 		Ctx:    ctx,
 		Amount: amount,
+		Memo:   memo,
+		Expiry: expiry,
 	}
 	mock.lockAddInvoice.Lock()
 	mock.calls.AddInvoice = append(mock.calls.AddInvoice, callInfo)
@@ -175,7 +368,7 @@ func (mock *lightningerMock) AddInvoice(ctx context.Context, amount lightning.Sa
 		)
 		return invoiceOut, errOut
 	}
-	return mock.AddInvoiceFunc(ctx, amount)
+	return mock.AddInvoiceFunc(ctx, amount, memo, expiry)
 }
 
 // AddInvoiceCalls gets all the calls that were made to AddInvoice.
@@ -185,10 +378,14 @@ func (mock *lightningerMock) AddInvoice(ctx context.Context, amount lightning.Sa
 func (mock *lightningerMock) AddInvoiceCalls() []struct {
 	Ctx    context.Context
 	Amount lightning.Satoshi
+	Memo   string
+	Expiry time.Duration
 } {
 	var calls []struct {
 		Ctx    context.Context
 		Amount lightning.Satoshi
+		Memo   string
+		Expiry time.Duration
 	}
 	mock.lockAddInvoice.RLock()
 	calls = mock.calls.AddInvoice
@@ -196,6 +393,216 @@ func (mock *lightningerMock) AddInvoiceCalls() []struct {
 	return calls
 }
 
+// BatchOpenChannel calls BatchOpenChannelFunc.
+func (mock *lightningerMock) BatchOpenChannel(ctx context.Context, channels []lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+	callInfo := struct {
+		Ctx      context.Context
+		Channels []lightning.NewChannel
+		FeeRate  lightning.SatPerVByte
+	}{
+		Ctx:      ctx,
+		Channels: channels,
+		FeeRate:  feeRate,
+	}
+	mock.lockBatchOpenChannel.Lock()
+	mock.calls.BatchOpenChannel = append(mock.calls.BatchOpenChannel, callInfo)
+	mock.lockBatchOpenChannel.Unlock()
+	if mock.BatchOpenChannelFunc == nil {
+		var (
+			sOut   string
+			errOut error
+		)
+		return sOut, errOut
+	}
+	return mock.BatchOpenChannelFunc(ctx, channels, feeRate)
+}
+
+// BatchOpenChannelCalls gets all the calls that were made to BatchOpenChannel.
+// Check the length with:
+//
+//	len(mockedlightninger.BatchOpenChannelCalls())
+func (mock *lightningerMock) BatchOpenChannelCalls() []struct {
+	Ctx      context.Context
+	Channels []lightning.NewChannel
+	FeeRate  lightning.SatPerVByte
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Channels []lightning.NewChannel
+		FeeRate  lightning.SatPerVByte
+	}
+	mock.lockBatchOpenChannel.RLock()
+	calls = mock.calls.BatchOpenChannel
+	mock.lockBatchOpenChannel.RUnlock()
+	return calls
+}
+
+// BuildRoute calls BuildRouteFunc.
+func (mock *lightningerMock) BuildRoute(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, hops []lightning.PubKey) (lightning.Route, error) {
+	callInfo := struct {
+		Ctx          context.Context
+		Invoice      lightning.Invoice
+		OutChannelID lightning.ChannelID
+		Hops         []lightning.PubKey
+	}{
+		Ctx:          ctx,
+		Invoice:      invoice,
+		OutChannelID: outChannelID,
+		Hops:         hops,
+	}
+	mock.lockBuildRoute.Lock()
+	mock.calls.BuildRoute = append(mock.calls.BuildRoute, callInfo)
+	mock.lockBuildRoute.Unlock()
+	if mock.BuildRouteFunc == nil {
+		var (
+			routeOut lightning.Route
+			errOut   error
+		)
+		return routeOut, errOut
+	}
+	return mock.BuildRouteFunc(ctx, invoice, outChannelID, hops)
+}
+
+// BuildRouteCalls gets all the calls that were made to BuildRoute.
+// Check the length with:
+//
+//	len(mockedlightninger.BuildRouteCalls())
+func (mock *lightningerMock) BuildRouteCalls() []struct {
+	Ctx          context.Context
+	Invoice      lightning.Invoice
+	OutChannelID lightning.ChannelID
+	Hops         []lightning.PubKey
+} {
+	var calls []struct {
+		Ctx          context.Context
+		Invoice      lightning.Invoice
+		OutChannelID lightning.ChannelID
+		Hops         []lightning.PubKey
+	}
+	mock.lockBuildRoute.RLock()
+	calls = mock.calls.BuildRoute
+	mock.lockBuildRoute.RUnlock()
+	return calls
+}
+
+// CancelInvoice calls CancelInvoiceFunc.
+func (mock *lightningerMock) CancelInvoice(ctx context.Context, invoice lightning.Invoice) error {
+	callInfo := struct {
+		Ctx     context.Context
+		Invoice lightning.Invoice
+	}{
+		Ctx:     ctx,
+		Invoice: invoice,
+	}
+	mock.lockCancelInvoice.Lock()
+	mock.calls.CancelInvoice = append(mock.calls.CancelInvoice, callInfo)
+	mock.lockCancelInvoice.Unlock()
+	if mock.CancelInvoiceFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.CancelInvoiceFunc(ctx, invoice)
+}
+
+// CancelInvoiceCalls gets all the calls that were made to CancelInvoice.
+// Check the length with:
+//
+//	len(mockedlightninger.CancelInvoiceCalls())
+func (mock *lightningerMock) CancelInvoiceCalls() []struct {
+	Ctx     context.Context
+	Invoice lightning.Invoice
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Invoice lightning.Invoice
+	}
+	mock.lockCancelInvoice.RLock()
+	calls = mock.calls.CancelInvoice
+	mock.lockCancelInvoice.RUnlock()
+	return calls
+}
+
+// ClosedPeers calls ClosedPeersFunc.
+func (mock *lightningerMock) ClosedPeers(ctx context.Context) ([]lightning.PubKey, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockClosedPeers.Lock()
+	mock.calls.ClosedPeers = append(mock.calls.ClosedPeers, callInfo)
+	mock.lockClosedPeers.Unlock()
+	if mock.ClosedPeersFunc == nil {
+		var (
+			pubKeysOut []lightning.PubKey
+			errOut     error
+		)
+		return pubKeysOut, errOut
+	}
+	return mock.ClosedPeersFunc(ctx)
+}
+
+// ClosedPeersCalls gets all the calls that were made to ClosedPeers.
+// Check the length with:
+//
+//	len(mockedlightninger.ClosedPeersCalls())
+func (mock *lightningerMock) ClosedPeersCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockClosedPeers.RLock()
+	calls = mock.calls.ClosedPeers
+	mock.lockClosedPeers.RUnlock()
+	return calls
+}
+
+// ConnectPeer calls ConnectPeerFunc.
+func (mock *lightningerMock) ConnectPeer(ctx context.Context, pubKey lightning.PubKey, address string) error {
+	callInfo := struct {
+		Ctx     context.Context
+		PubKey  lightning.PubKey
+		Address string
+	}{
+		Ctx:     ctx,
+		PubKey:  pubKey,
+		Address: address,
+	}
+	mock.lockConnectPeer.Lock()
+	mock.calls.ConnectPeer = append(mock.calls.ConnectPeer, callInfo)
+	mock.lockConnectPeer.Unlock()
+	if mock.ConnectPeerFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.ConnectPeerFunc(ctx, pubKey, address)
+}
+
+// ConnectPeerCalls gets all the calls that were made to ConnectPeer.
+// Check the length with:
+//
+//	len(mockedlightninger.ConnectPeerCalls())
+func (mock *lightningerMock) ConnectPeerCalls() []struct {
+	Ctx     context.Context
+	PubKey  lightning.PubKey
+	Address string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		PubKey  lightning.PubKey
+		Address string
+	}
+	mock.lockConnectPeer.RLock()
+	calls = mock.calls.ConnectPeer
+	mock.lockConnectPeer.RUnlock()
+	return calls
+}
+
 // DescribeGraph calls DescribeGraphFunc.
 func (mock *lightningerMock) DescribeGraph(ctx context.Context) (*lightning.Graph, error) {
 	callInfo := struct {
@@ -348,6 +755,46 @@ func (mock *lightningerMock) GetInfoCalls() []struct {
 	return calls
 }
 
+// GetNode calls GetNodeFunc.
+func (mock *lightningerMock) GetNode(ctx context.Context, pubKey lightning.PubKey) (lightning.Node, error) {
+	callInfo := struct {
+		Ctx    context.Context
+		PubKey lightning.PubKey
+	}{
+		Ctx:    ctx,
+		PubKey: pubKey,
+	}
+	mock.lockGetNode.Lock()
+	mock.calls.GetNode = append(mock.calls.GetNode, callInfo)
+	mock.lockGetNode.Unlock()
+	if mock.GetNodeFunc == nil {
+		var (
+			nodeOut lightning.Node
+			errOut  error
+		)
+		return nodeOut, errOut
+	}
+	return mock.GetNodeFunc(ctx, pubKey)
+}
+
+// GetNodeCalls gets all the calls that were made to GetNode.
+// Check the length with:
+//
+//	len(mockedlightninger.GetNodeCalls())
+func (mock *lightningerMock) GetNodeCalls() []struct {
+	Ctx    context.Context
+	PubKey lightning.PubKey
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PubKey lightning.PubKey
+	}
+	mock.lockGetNode.RLock()
+	calls = mock.calls.GetNode
+	mock.lockGetNode.RUnlock()
+	return calls
+}
+
 // ListChannels calls ListChannelsFunc.
 func (mock *lightningerMock) ListChannels(ctx context.Context) (lightning.Channels, error) {
 	callInfo := struct {
@@ -384,32 +831,206 @@ func (mock *lightningerMock) ListChannelsCalls() []struct {
 	return calls
 }
 
+// OpenChannel calls OpenChannelFunc.
+func (mock *lightningerMock) OpenChannel(ctx context.Context, channel lightning.NewChannel, feeRate lightning.SatPerVByte) (string, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Channel lightning.NewChannel
+		FeeRate lightning.SatPerVByte
+	}{
+		Ctx:     ctx,
+		Channel: channel,
+		FeeRate: feeRate,
+	}
+	mock.lockOpenChannel.Lock()
+	mock.calls.OpenChannel = append(mock.calls.OpenChannel, callInfo)
+	mock.lockOpenChannel.Unlock()
+	if mock.OpenChannelFunc == nil {
+		var (
+			sOut   string
+			errOut error
+		)
+		return sOut, errOut
+	}
+	return mock.OpenChannelFunc(ctx, channel, feeRate)
+}
+
+// OpenChannelCalls gets all the calls that were made to OpenChannel.
+// Check the length with:
+//
+//	len(mockedlightninger.OpenChannelCalls())
+func (mock *lightningerMock) OpenChannelCalls() []struct {
+	Ctx     context.Context
+	Channel lightning.NewChannel
+	FeeRate lightning.SatPerVByte
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Channel lightning.NewChannel
+		FeeRate lightning.SatPerVByte
+	}
+	mock.lockOpenChannel.RLock()
+	calls = mock.calls.OpenChannel
+	mock.lockOpenChannel.RUnlock()
+	return calls
+}
+
+// PendingPeers calls PendingPeersFunc.
+func (mock *lightningerMock) PendingPeers(ctx context.Context) ([]lightning.PubKey, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockPendingPeers.Lock()
+	mock.calls.PendingPeers = append(mock.calls.PendingPeers, callInfo)
+	mock.lockPendingPeers.Unlock()
+	if mock.PendingPeersFunc == nil {
+		var (
+			pubKeysOut []lightning.PubKey
+			errOut     error
+		)
+		return pubKeysOut, errOut
+	}
+	return mock.PendingPeersFunc(ctx)
+}
+
+// PendingPeersCalls gets all the calls that were made to PendingPeers.
+// Check the length with:
+//
+//	len(mockedlightninger.PendingPeersCalls())
+func (mock *lightningerMock) PendingPeersCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockPendingPeers.RLock()
+	calls = mock.calls.PendingPeers
+	mock.lockPendingPeers.RUnlock()
+	return calls
+}
+
+// ProbeRoute calls ProbeRouteFunc.
+func (mock *lightningerMock) ProbeRoute(ctx context.Context, route lightning.Route) (lightning.Probe, error) {
+	callInfo := struct {
+		Ctx   context.Context
+		Route lightning.Route
+	}{
+		Ctx:   ctx,
+		Route: route,
+	}
+	mock.lockProbeRoute.Lock()
+	mock.calls.ProbeRoute = append(mock.calls.ProbeRoute, callInfo)
+	mock.lockProbeRoute.Unlock()
+	if mock.ProbeRouteFunc == nil {
+		var (
+			probeOut lightning.Probe
+			errOut   error
+		)
+		return probeOut, errOut
+	}
+	return mock.ProbeRouteFunc(ctx, route)
+}
+
+// ProbeRouteCalls gets all the calls that were made to ProbeRoute.
+// Check the length with:
+//
+//	len(mockedlightninger.ProbeRouteCalls())
+func (mock *lightningerMock) ProbeRouteCalls() []struct {
+	Ctx   context.Context
+	Route lightning.Route
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Route lightning.Route
+	}
+	mock.lockProbeRoute.RLock()
+	calls = mock.calls.ProbeRoute
+	mock.lockProbeRoute.RUnlock()
+	return calls
+}
+
+// QueryRoute calls QueryRouteFunc.
+func (mock *lightningerMock) QueryRoute(ctx context.Context, amount lightning.Satoshi, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Route, error) {
+	callInfo := struct {
+		Ctx           context.Context
+		Amount        lightning.Satoshi
+		OutChannelID  lightning.ChannelID
+		LastHopPubKey lightning.PubKey
+		MaxFee        lightning.FeePPM
+	}{
+		Ctx:           ctx,
+		Amount:        amount,
+		OutChannelID:  outChannelID,
+		LastHopPubKey: lastHopPubKey,
+		MaxFee:        maxFee,
+	}
+	mock.lockQueryRoute.Lock()
+	mock.calls.QueryRoute = append(mock.calls.QueryRoute, callInfo)
+	mock.lockQueryRoute.Unlock()
+	if mock.QueryRouteFunc == nil {
+		var (
+			routeOut lightning.Route
+			errOut   error
+		)
+		return routeOut, errOut
+	}
+	return mock.QueryRouteFunc(ctx, amount, outChannelID, lastHopPubKey, maxFee)
+}
+
+// QueryRouteCalls gets all the calls that were made to QueryRoute.
+// Check the length with:
+//
+//	len(mockedlightninger.QueryRouteCalls())
+func (mock *lightningerMock) QueryRouteCalls() []struct {
+	Ctx           context.Context
+	Amount        lightning.Satoshi
+	OutChannelID  lightning.ChannelID
+	LastHopPubKey lightning.PubKey
+	MaxFee        lightning.FeePPM
+} {
+	var calls []struct {
+		Ctx           context.Context
+		Amount        lightning.Satoshi
+		OutChannelID  lightning.ChannelID
+		LastHopPubKey lightning.PubKey
+		MaxFee        lightning.FeePPM
+	}
+	mock.lockQueryRoute.RLock()
+	calls = mock.calls.QueryRoute
+	mock.lockQueryRoute.RUnlock()
+	return calls
+}
+
 // SendPayment calls SendPaymentFunc.
-func (mock *lightningerMock) SendPayment(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Satoshi, error) {
+func (mock *lightningerMock) SendPayment(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
 	callInfo := struct {
 		Ctx           context.Context
 		Invoice       lightning.Invoice
 		OutChannelID  lightning.ChannelID
 		LastHopPubKey lightning.PubKey
 		MaxFee        lightning.FeePPM
+		MaxParts      uint32
 	}{
 		Ctx:           ctx,
 		Invoice:       invoice,
 		OutChannelID:  outChannelID,
 		LastHopPubKey: lastHopPubKey,
 		MaxFee:        maxFee,
+		MaxParts:      maxParts,
 	}
 	mock.lockSendPayment.Lock()
 	mock.calls.SendPayment = append(mock.calls.SendPayment, callInfo)
 	mock.lockSendPayment.Unlock()
 	if mock.SendPaymentFunc == nil {
 		var (
-			satoshiOut lightning.Satoshi
+			paymentOut lightning.Payment
 			errOut     error
 		)
-		return satoshiOut, errOut
+		return paymentOut, errOut
 	}
-	return mock.SendPaymentFunc(ctx, invoice, outChannelID, lastHopPubKey, maxFee)
+	return mock.SendPaymentFunc(ctx, invoice, outChannelID, lastHopPubKey, maxFee, maxParts)
 }
 
 // SendPaymentCalls gets all the calls that were made to SendPayment.
@@ -422,6 +1043,7 @@ func (mock *lightningerMock) SendPaymentCalls() []struct {
 	OutChannelID  lightning.ChannelID
 	LastHopPubKey lightning.PubKey
 	MaxFee        lightning.FeePPM
+	MaxParts      uint32
 } {
 	var calls []struct {
 		Ctx           context.Context
@@ -429,6 +1051,7 @@ func (mock *lightningerMock) SendPaymentCalls() []struct {
 		OutChannelID  lightning.ChannelID
 		LastHopPubKey lightning.PubKey
 		MaxFee        lightning.FeePPM
+		MaxParts      uint32
 	}
 	mock.lockSendPayment.RLock()
 	calls = mock.calls.SendPayment
@@ -436,6 +1059,50 @@ func (mock *lightningerMock) SendPaymentCalls() []struct {
 	return calls
 }
 
+// SendToRoute calls SendToRouteFunc.
+func (mock *lightningerMock) SendToRoute(ctx context.Context, invoice lightning.Invoice, route lightning.Route) (lightning.Payment, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Invoice lightning.Invoice
+		Route   lightning.Route
+	}{
+		Ctx:     ctx,
+		Invoice: invoice,
+		Route:   route,
+	}
+	mock.lockSendToRoute.Lock()
+	mock.calls.SendToRoute = append(mock.calls.SendToRoute, callInfo)
+	mock.lockSendToRoute.Unlock()
+	if mock.SendToRouteFunc == nil {
+		var (
+			paymentOut lightning.Payment
+			errOut     error
+		)
+		return paymentOut, errOut
+	}
+	return mock.SendToRouteFunc(ctx, invoice, route)
+}
+
+// SendToRouteCalls gets all the calls that were made to SendToRoute.
+// Check the length with:
+//
+//	len(mockedlightninger.SendToRouteCalls())
+func (mock *lightningerMock) SendToRouteCalls() []struct {
+	Ctx     context.Context
+	Invoice lightning.Invoice
+	Route   lightning.Route
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Invoice lightning.Invoice
+		Route   lightning.Route
+	}
+	mock.lockSendToRoute.RLock()
+	calls = mock.calls.SendToRoute
+	mock.lockSendToRoute.RUnlock()
+	return calls
+}
+
 // SetFees calls SetFeesFunc.
 func (mock *lightningerMock) SetFees(ctx context.Context, channelID lightning.ChannelID, fee lightning.FeePPM, maxHTLC lightning.MilliSatoshi) error {
 	callInfo := struct {
@@ -483,6 +1150,46 @@ func (mock *lightningerMock) SetFeesCalls() []struct {
 	return calls
 }
 
+// SettledInvoices calls SettledInvoicesFunc.
+func (mock *lightningerMock) SettledInvoices(ctx context.Context, since time.Time) ([]lightning.SettledInvoice, error) {
+	callInfo := struct {
+		Ctx   context.Context
+		Since time.Time
+	}{
+		Ctx:   ctx,
+		Since: since,
+	}
+	mock.lockSettledInvoices.Lock()
+	mock.calls.SettledInvoices = append(mock.calls.SettledInvoices, callInfo)
+	mock.lockSettledInvoices.Unlock()
+	if mock.SettledInvoicesFunc == nil {
+		var (
+			settledInvoicesOut []lightning.SettledInvoice
+			errOut             error
+		)
+		return settledInvoicesOut, errOut
+	}
+	return mock.SettledInvoicesFunc(ctx, since)
+}
+
+// SettledInvoicesCalls gets all the calls that were made to SettledInvoices.
+// Check the length with:
+//
+//	len(mockedlightninger.SettledInvoicesCalls())
+func (mock *lightningerMock) SettledInvoicesCalls() []struct {
+	Ctx   context.Context
+	Since time.Time
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Since time.Time
+	}
+	mock.lockSettledInvoices.RLock()
+	calls = mock.calls.SettledInvoices
+	mock.lockSettledInvoices.RUnlock()
+	return calls
+}
+
 // SubscribeChannelUpdates calls SubscribeChannelUpdatesFunc.
 func (mock *lightningerMock) SubscribeChannelUpdates(ctx context.Context) (<-chan lightning.Channels, <-chan error, error) {
 	callInfo := struct {
@@ -519,3 +1226,81 @@ func (mock *lightningerMock) SubscribeChannelUpdatesCalls() []struct {
 	mock.lockSubscribeChannelUpdates.RUnlock()
 	return calls
 }
+
+// dialerMock is a mock implementation of dialer.
+//
+//	func TestSomethingThatUsesdialer(t *testing.T) {
+//
+//		// make and configure a mocked dialer
+//		mockeddialer := &dialerMock{
+//			DialContextFunc: func(ctx context.Context, network string, address string) (net.Conn, error) {
+//				panic("mock out the DialContext method")
+//			},
+//		}
+//
+//		// use mockeddialer in code that requires dialer
+//		// and then make assertions.
+//
+//	}
+type dialerMock struct {
+	// DialContextFunc mocks the DialContext method.
+	DialContextFunc func(ctx context.Context, network string, address string) (net.Conn, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// DialContext holds details about calls to the DialContext method.
+		DialContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Network is the network argument value.
+			Network string
+			// Address is the address argument value.
+			Address string
+		}
+	}
+	lockDialContext sync.RWMutex
+}
+
+// DialContext calls DialContextFunc.
+func (mock *dialerMock) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Network string
+		Address string
+	}{
+		Ctx:     ctx,
+		Network: network,
+		Address: address,
+	}
+	mock.lockDialContext.Lock()
+	mock.calls.DialContext = append(mock.calls.DialContext, callInfo)
+	mock.lockDialContext.Unlock()
+	if mock.DialContextFunc == nil {
+		var (
+			connOut net.Conn
+			errOut  error
+		)
+		return connOut, errOut
+	}
+	return mock.DialContextFunc(ctx, network, address)
+}
+
+// DialContextCalls gets all the calls that were made to DialContext.
+// Check the length with:
+//
+//	len(mockeddialer.DialContextCalls())
+func (mock *dialerMock) DialContextCalls() []struct {
+	Ctx     context.Context
+	Network string
+	Address string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Network string
+		Address string
+	}
+	mock.lockDialContext.RLock()
+	calls = mock.calls.DialContext
+	mock.lockDialContext.RUnlock()
+	return calls
+}
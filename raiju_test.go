@@ -2,7 +2,12 @@ package raiju
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"reflect"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,12 +25,36 @@ const (
 	pubKeyG         = lightning.PubKey("G")
 	alias           = "raiju"
 	clearnetAddress = "44.127.188.136:9735"
+	torAddress      = "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion:9735"
 )
 
 var (
 	updated, _ = time.Parse(time.RFC3339, "2020-01-02T15:04:05Z")
 )
 
+// lineGraph connects the nodes in order with single satoshi channels.
+func lineGraph(pubKeys ...lightning.PubKey) *lightning.Graph {
+	g := &lightning.Graph{
+		Nodes: make([]lightning.Node, len(pubKeys)),
+		Edges: make([]lightning.Edge, 0, len(pubKeys)),
+	}
+
+	for i, k := range pubKeys {
+		g.Nodes[i] = lightning.Node{
+			PubKey:    k,
+			Alias:     string(k),
+			Updated:   updated,
+			Addresses: []string{clearnetAddress},
+		}
+
+		if i > 0 {
+			g.Edges = append(g.Edges, lightning.Edge{Capacity: 1, Node1: pubKeys[i-1], Node2: k})
+		}
+	}
+
+	return g
+}
+
 func TestRaiju_Candidates(t *testing.T) {
 	type fields struct {
 		l lightninger
@@ -125,7 +154,7 @@ func TestRaiju_Candidates(t *testing.T) {
 			},
 			args: args{
 				request: CandidatesRequest{
-					PubKey:              lightning.PubKey("A"),
+					PubKeys:             []lightning.PubKey{pubKeyA},
 					MinCapacity:         1,
 					MinChannels:         1,
 					MinDistance:         2,
@@ -144,11 +173,14 @@ func TestRaiju_Candidates(t *testing.T) {
 						Updated:   updated,
 						Addresses: []string{clearnetAddress},
 					},
-					Distance:        3,
-					DistantNeigbors: 0,
-					Channels:        1,
-					Capacity:        1,
-					Neighbors:       []lightning.PubKey{pubKeyC},
+					Root:              pubKeyA,
+					Distance:          3,
+					DistantNeigbors:   0,
+					Channels:          1,
+					Capacity:          1,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyC},
+					PeersInSubnet:     1,
 				},
 				{
 					Node: lightning.Node{
@@ -157,11 +189,184 @@ func TestRaiju_Candidates(t *testing.T) {
 						Updated:   updated,
 						Addresses: []string{clearnetAddress},
 					},
-					Distance:        2,
-					DistantNeigbors: 1,
-					Channels:        2,
-					Capacity:        2,
-					Neighbors:       []lightning.PubKey{pubKeyB, pubKeyD},
+					Root:              pubKeyA,
+					Distance:          2,
+					DistantNeigbors:   1,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyB, pubKeyD},
+					PeersInSubnet:     1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "excluded, pending, and closed peers are filtered out",
+			fields: fields{
+				l: &lightningerMock{
+					DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+						// a linear network (A) <=> (B) <=> (C) <=> (D) <=> (E)
+						return lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE), nil
+					},
+					PendingPeersFunc: func(ctx context.Context) ([]lightning.PubKey, error) {
+						return []lightning.PubKey{pubKeyC}, nil
+					},
+					ClosedPeersFunc: func(ctx context.Context) ([]lightning.PubKey, error) {
+						return []lightning.PubKey{pubKeyD}, nil
+					},
+				},
+			},
+			args: args{
+				request: CandidatesRequest{
+					PubKeys:       []lightning.PubKey{pubKeyA},
+					MinCapacity:   1,
+					MinChannels:   1,
+					MinDistance:   2,
+					MinUpdated:    updated.Add(time.Hour * -3),
+					Limit:         10,
+					Clearnet:      true,
+					ExcludeClosed: true,
+					Exclude: NodeList{
+						Aliases: []*regexp.Regexp{regexp.MustCompile("^E$")},
+					},
+				},
+			},
+			want:    []RelativeNode{},
+			wantErr: false,
+		},
+		{
+			name: "nodes observed below min uptime are filtered out",
+			fields: fields{
+				l: &lightningerMock{
+					DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+						// a linear network (A) <=> (B) <=> (C) <=> (D) <=> (E)
+						return lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE), nil
+					},
+				},
+			},
+			args: args{
+				request: CandidatesRequest{
+					PubKeys:     []lightning.PubKey{pubKeyA},
+					MinCapacity: 1,
+					MinChannels: 2,
+					MinDistance: 2,
+					MinUpdated:  updated.Add(time.Hour * -3),
+					Limit:       10,
+					Clearnet:    true,
+					Uptime: map[lightning.PubKey]float64{
+						pubKeyC: 50,
+						pubKeyE: 100,
+					},
+					MinUptime: 90,
+				},
+			},
+			want: []RelativeNode{
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyD,
+						Alias:     "D",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          3,
+					DistantNeigbors:   1,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyC, pubKeyE},
+					PeersInSubnet:     1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "network filters require addresses on each network",
+			fields: fields{
+				l: &lightningerMock{
+					DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+						// a linear network (A) <=> (B) <=> (C) <=> (D) <=> (E)
+						g := lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE)
+						g.Nodes[2].Addresses = []string{clearnetAddress, torAddress}
+						g.Nodes[3].Addresses = []string{torAddress}
+						g.Nodes[4].Addresses = []string{"[2001:db8::1]:9735", torAddress}
+						return g, nil
+					},
+				},
+			},
+			args: args{
+				request: CandidatesRequest{
+					PubKeys:     []lightning.PubKey{pubKeyA},
+					MinCapacity: 1,
+					MinChannels: 1,
+					MinDistance: 2,
+					MinUpdated:  updated.Add(time.Hour * -3),
+					Limit:       10,
+					Hybrid:      true,
+					Networks:    []lightning.AddressType{lightning.IPv4},
+				},
+			},
+			want: []RelativeNode{
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyC,
+						Alias:     "C",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress, torAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          2,
+					DistantNeigbors:   1,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyB, pubKeyD},
+					PeersInSubnet:     1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "include list limits candidates",
+			fields: fields{
+				l: &lightningerMock{
+					DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+						// a linear network (A) <=> (B) <=> (C) <=> (D)
+						return lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD), nil
+					},
+				},
+			},
+			args: args{
+				request: CandidatesRequest{
+					PubKeys:     []lightning.PubKey{pubKeyA},
+					MinCapacity: 1,
+					MinChannels: 1,
+					MinDistance: 2,
+					MinUpdated:  updated.Add(time.Hour * -3),
+					Limit:       10,
+					Clearnet:    true,
+					Include: NodeList{
+						PubKeys: []lightning.PubKey{pubKeyC},
+					},
+				},
+			},
+			want: []RelativeNode{
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyC,
+						Alias:     "C",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          2,
+					DistantNeigbors:   1,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyB, pubKeyD},
+					PeersInSubnet:     1,
 				},
 			},
 			wantErr: false,
@@ -260,7 +465,7 @@ func TestRaiju_Candidates(t *testing.T) {
 			},
 			args: args{
 				request: CandidatesRequest{
-					PubKey:              lightning.PubKey("A"),
+					PubKeys:             []lightning.PubKey{pubKeyA},
 					MinCapacity:         1,
 					MinChannels:         1,
 					MinDistance:         3,
@@ -279,11 +484,178 @@ func TestRaiju_Candidates(t *testing.T) {
 						Updated:   updated,
 						Addresses: []string{clearnetAddress},
 					},
-					Distance:        3,
-					DistantNeigbors: 0,
-					Channels:        2,
-					Capacity:        2,
-					Neighbors:       []lightning.PubKey{pubKeyC, pubKeyE},
+					Root:              pubKeyA,
+					Distance:          3,
+					DistantNeigbors:   0,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyC, pubKeyE},
+					PeersInSubnet:     2,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "centrality should rank candidates by gain",
+			fields: fields{
+				l: &lightningerMock{
+					DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+						// a branching network (A) <=> (B) <=> (C) <=> (D) <=> (G)
+						//                              \
+						//                              (E) <=> (F)
+						return &lightning.Graph{
+							Nodes: []lightning.Node{
+								{
+									PubKey:    pubKeyA,
+									Alias:     "A",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyB,
+									Alias:     "B",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyC,
+									Alias:     "C",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyD,
+									Alias:     "D",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyE,
+									Alias:     "E",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyF,
+									Alias:     "F",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+								{
+									PubKey:    pubKeyG,
+									Alias:     "G",
+									Updated:   updated,
+									Addresses: []string{clearnetAddress},
+								},
+							},
+							Edges: []lightning.Edge{
+								{
+									Capacity: 1,
+									Node1:    pubKeyA,
+									Node2:    pubKeyB,
+								},
+								{
+									Capacity: 1,
+									Node1:    pubKeyB,
+									Node2:    pubKeyC,
+								},
+								{
+									Capacity: 1,
+									Node1:    pubKeyC,
+									Node2:    pubKeyD,
+								},
+								{
+									Capacity: 1,
+									Node1:    pubKeyD,
+									Node2:    pubKeyG,
+								},
+								{
+									Capacity: 1,
+									Node1:    pubKeyB,
+									Node2:    pubKeyE,
+								},
+								{
+									Capacity: 1,
+									Node1:    pubKeyE,
+									Node2:    pubKeyF,
+								},
+							},
+						}, nil
+					},
+					GetInfoFunc: func(ctx context.Context) (*lightning.Info, error) {
+						return &lightning.Info{
+							PubKey: pubKey,
+						}, nil
+					},
+				},
+			},
+			args: args{
+				request: CandidatesRequest{
+					PubKeys:             []lightning.PubKey{pubKeyA},
+					MinCapacity:         1,
+					MinChannels:         1,
+					MinDistance:         3,
+					MinDistantNeighbors: 0,
+					MinUpdated:          updated.Add(time.Hour * -3),
+					Assume:              []lightning.PubKey{},
+					Limit:               10,
+					Clearnet:            true,
+					CentralitySamples:   10,
+					Scorer:              CentralityScorer{},
+				},
+			},
+			want: []RelativeNode{
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyG,
+						Alias:     "G",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          4,
+					DistantNeigbors:   1,
+					Channels:          1,
+					Capacity:          1,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyD},
+					Centrality:        6,
+					PeersInSubnet:     1,
+				},
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyD,
+						Alias:     "D",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          3,
+					DistantNeigbors:   1,
+					Channels:          2,
+					Capacity:          2,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyC, pubKeyG},
+					Centrality:        6,
+					PeersInSubnet:     1,
+				},
+				{
+					Node: lightning.Node{
+						PubKey:    pubKeyF,
+						Alias:     "F",
+						Updated:   updated,
+						Addresses: []string{clearnetAddress},
+					},
+					Root:              pubKeyA,
+					Distance:          3,
+					DistantNeigbors:   0,
+					Channels:          1,
+					Capacity:          1,
+					MedianChannelSize: 1,
+					Neighbors:         []lightning.PubKey{pubKeyE},
+					Centrality:        4,
+					PeersInSubnet:     1,
 				},
 			},
 			wantErr: false,
@@ -306,6 +678,151 @@ func TestRaiju_Candidates(t *testing.T) {
 	}
 }
 
+func Test_relativeNodes_roots(t *testing.T) {
+	// a linear network with roots on both ends (A) <=> (B) <=> (C) <=> (D) <=> (E) <=> (F) <=> (G)
+	g := lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD, pubKeyE, pubKeyF, pubKeyG)
+
+	nodes, err := relativeNodes(g, CandidatesRequest{
+		PubKeys: []lightning.PubKey{pubKeyA, pubKeyG},
+	})
+	if err != nil {
+		t.Fatalf("relativeNodes() error = %v", err)
+	}
+
+	type want struct {
+		distance int64
+		root     lightning.PubKey
+	}
+	wants := map[lightning.PubKey]want{
+		pubKeyA: {0, pubKeyA},
+		pubKeyB: {1, pubKeyA},
+		pubKeyC: {2, pubKeyA},
+		// equally distant from both, so the first root wins
+		pubKeyD: {3, pubKeyA},
+		pubKeyE: {2, pubKeyG},
+		pubKeyF: {1, pubKeyG},
+		pubKeyG: {0, pubKeyG},
+	}
+	for k, w := range wants {
+		if got := (want{nodes[k].Distance, nodes[k].Root}); got != w {
+			t.Errorf("relativeNodes() %s = %v, want %v", k, got, w)
+		}
+	}
+}
+
+func Test_relativeNodes_stats(t *testing.T) {
+	// B has channels with A, C, and D
+	g := &lightning.Graph{
+		Nodes: []lightning.Node{{PubKey: pubKeyA}, {PubKey: pubKeyB}, {PubKey: pubKeyC}, {PubKey: pubKeyD}},
+		Edges: []lightning.Edge{
+			{
+				Capacity:      1000000,
+				Node1:         pubKeyA,
+				Node2:         pubKeyB,
+				Node1Policy:   &lightning.RoutingPolicy{FeeRate: 10},
+				Node2Policy:   &lightning.RoutingPolicy{FeeRate: 100},
+				FundingHeight: 800000,
+			},
+			{
+				Capacity:      2000000,
+				Node1:         pubKeyB,
+				Node2:         pubKeyC,
+				Node1Policy:   &lightning.RoutingPolicy{FeeRate: 200},
+				Node2Policy:   &lightning.RoutingPolicy{FeeRate: 20},
+				FundingHeight: 840000,
+			},
+			{
+				Capacity:      5000000,
+				Node1:         pubKeyD,
+				Node2:         pubKeyB,
+				Node1Policy:   &lightning.RoutingPolicy{FeeRate: 60},
+				Node2Policy:   &lightning.RoutingPolicy{FeeRate: 5000, Disabled: true},
+				FundingHeight: 849000,
+			},
+		},
+		Height: 850000,
+	}
+
+	nodes, err := relativeNodes(g, CandidatesRequest{PubKeys: []lightning.PubKey{pubKeyA}})
+	if err != nil {
+		t.Fatalf("relativeNodes() error = %v", err)
+	}
+
+	b := nodes[pubKeyB]
+	got := RelativeNode{
+		MedianOutboundFee: b.MedianOutboundFee,
+		AvgOutboundFee:    b.AvgOutboundFee,
+		MedianInboundFee:  b.MedianInboundFee,
+		AvgInboundFee:     b.AvgInboundFee,
+		MedianChannelSize: b.MedianChannelSize,
+		DisabledChannels:  b.DisabledChannels,
+		OldestChannelAge:  b.OldestChannelAge,
+	}
+	want := RelativeNode{
+		MedianOutboundFee: 150,
+		AvgOutboundFee:    150,
+		MedianInboundFee:  20,
+		AvgInboundFee:     30,
+		MedianChannelSize: 2000000,
+		DisabledChannels:  1,
+		OldestChannelAge:  50000,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relativeNodes() B = %+v, want %+v", got, want)
+	}
+}
+
+func Test_exclusions(t *testing.T) {
+	node := RelativeNode{
+		Node:              lightning.Node{PubKey: pubKeyA, Updated: updated},
+		Channels:          4,
+		DisabledChannels:  1,
+		MedianOutboundFee: 100,
+		MedianInboundFee:  500,
+		MedianChannelSize: 2000000,
+		OldestChannelAge:  1000,
+	}
+
+	tests := []struct {
+		name    string
+		request CandidatesRequest
+		want    bool
+	}{
+		{
+			name:    "no limits",
+			request: CandidatesRequest{},
+			want:    true,
+		},
+		{
+			name:    "within limits",
+			request: CandidatesRequest{MaxMedianOutboundFee: 100, MaxMedianInboundFee: 500, MinMedianChannelSize: 2000000, MinEnabledPercent: 75, MinChannelAge: 1000},
+			want:    true,
+		},
+		{
+			name:    "expensive inbound",
+			request: CandidatesRequest{MaxMedianInboundFee: 499},
+			want:    false,
+		},
+		{
+			name:    "too many disabled",
+			request: CandidatesRequest{MinEnabledPercent: 80},
+			want:    false,
+		},
+		{
+			name:    "too young",
+			request: CandidatesRequest{MinChannelAge: 1001},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exclusions(tt.request, node); (len(got) == 0) != tt.want {
+				t.Errorf("exclusions() = %v, want candidate %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_sortDistance_Less(t *testing.T) {
 	type args struct {
 		i int
@@ -717,14 +1234,14 @@ func TestRaiju_Rebalance(t *testing.T) {
 		name    string
 		fields  fields
 		args    args
-		want    map[lightning.ChannelID]float64
+		want    RebalanceResults
 		wantErr bool
 	}{
 		{
 			name: "rebalance all only one channel",
 			fields: fields{
 				l: &lightningerMock{
-					AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi) (lightning.Invoice, error) {
+					AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
 						return lightning.Invoice(""), nil
 					},
 					GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
@@ -744,16 +1261,17 @@ func TestRaiju_Rebalance(t *testing.T) {
 					ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
 						return lightning.Channels{}, nil
 					},
-					SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM) (lightning.Satoshi, error) {
-						return 0, nil
+					SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+						return lightning.Payment{}, nil
 					},
 				},
 			},
 			args: args{
+				ctx:        context.Background(),
 				maxPercent: 5,
 				maxFee:     lightning.FeePPM(1024),
 			},
-			want:    map[lightning.ChannelID]float64{},
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -762,7 +1280,7 @@ func TestRaiju_Rebalance(t *testing.T) {
 			r := Raiju{
 				l: tt.fields.l,
 			}
-			got, err := r.Rebalance(tt.args.ctx, tt.args.maxPercent, tt.args.maxFee)
+			got, err := r.Rebalance(tt.args.ctx, RebalanceRequest{MaxPercent: tt.args.maxPercent, MaxFee: tt.args.maxFee})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Raiju.Rebalance() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -773,6 +1291,424 @@ func TestRaiju_Rebalance(t *testing.T) {
 	}
 }
 
+func TestRaiju_Rebalance_potentialFee(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	high := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyB},
+		ChannelID:     1,
+		LocalBalance:  900,
+		LocalFee:      5,
+		RemoteBalance: 100,
+	}
+	low := lightning.Channel{
+		Edge:          lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyC},
+		ChannelID:     2,
+		LocalBalance:  100,
+		LocalFee:      500,
+		RemoteBalance: 900,
+	}
+
+	tests := []struct {
+		name       string
+		maxPercent float64
+		wantPaid   bool
+	}{
+		{
+			name:       "skips a pair whose low channel would keep its fee",
+			maxPercent: 5,
+			wantPaid:   false,
+		},
+		{
+			name:       "rebalances a pair whose low channel would lower its fee",
+			maxPercent: 20,
+			wantPaid:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := 0
+			r := Raiju{
+				l: &lightningerMock{
+					GetInfoFunc: func(ctx context.Context) (*lightning.Info, error) {
+						return &lightning.Info{PubKey: pubKey}, nil
+					},
+					ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
+						return lightning.Channels{high, low}, nil
+					},
+					GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+						if channelID == high.ChannelID {
+							return high, nil
+						}
+						return low, nil
+					},
+					AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+						return lightning.Invoice(""), nil
+					},
+					SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+						payments++
+						return lightning.Payment{Fee: 1}, nil
+					},
+				},
+				f: f,
+			}
+
+			_, err := r.Rebalance(context.Background(), RebalanceRequest{MaxPercent: tt.maxPercent, Strategy: RandomStrategy})
+			if err != nil {
+				t.Fatalf("Raiju.Rebalance() error = %v", err)
+			}
+			if paid := payments > 0; paid != tt.wantPaid {
+				t.Errorf("Raiju.Rebalance() paid = %v, want %v", paid, tt.wantPaid)
+			}
+		})
+	}
+}
+
+func TestRaiju_RebalancePair(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type args struct {
+		ctx     context.Context
+		out     lightning.ChannelID
+		in      lightning.ChannelID
+		request RebalanceRequest
+	}
+	tests := []struct {
+		name         string
+		failures     int
+		args         args
+		want         lightning.Satoshi
+		wantFee      lightning.Satoshi
+		wantAttempts int
+		wantHop      lightning.PubKey
+		wantPPM      lightning.FeePPM
+		wantErr      bool
+	}{
+		{
+			name: "pushes a percent through the in channel's peer at the default fee",
+			args: args{
+				ctx:     context.Background(),
+				out:     1,
+				in:      2,
+				request: RebalanceRequest{MaxPercent: 10},
+			},
+			want:         10,
+			wantFee:      2,
+			wantAttempts: 2,
+			wantHop:      pubKeyC,
+			wantPPM:      500,
+		},
+		{
+			name: "pushes an amount at the max fee",
+			args: args{
+				ctx:     context.Background(),
+				out:     1,
+				in:      2,
+				request: RebalanceRequest{MaxPercent: 50, Amount: 3, MaxFee: 100},
+			},
+			want:         3,
+			wantFee:      1,
+			wantAttempts: 1,
+			wantHop:      pubKeyC,
+			wantPPM:      100,
+		},
+		{
+			name:     "stops after the max attempts",
+			failures: 5,
+			args: args{
+				ctx:     context.Background(),
+				out:     1,
+				in:      2,
+				request: RebalanceRequest{Amount: 5, MaxAttempts: 2},
+			},
+			want:         0,
+			wantAttempts: 2,
+			wantHop:      pubKeyC,
+			wantPPM:      500,
+		},
+		{
+			name: "canceled context stops before paying",
+			args: args{
+				ctx:     canceled,
+				out:     1,
+				in:      2,
+				request: RebalanceRequest{Amount: 5},
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount over the local balance is rejected",
+			args: args{
+				ctx:     context.Background(),
+				out:     1,
+				in:      2,
+				request: RebalanceRequest{Amount: 101},
+			},
+			wantErr: true,
+		},
+		{
+			name: "channel can't be rebalanced into itself",
+			args: args{
+				ctx:     context.Background(),
+				out:     1,
+				in:      1,
+				request: RebalanceRequest{MaxPercent: 10},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hop lightning.PubKey
+			var ppm lightning.FeePPM
+			payments := 0
+			r := Raiju{
+				l: &lightningerMock{
+					AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+						return lightning.Invoice(""), nil
+					},
+					GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+						return lightning.Channel{
+							Edge:         lightning.Edge{Capacity: 100},
+							ChannelID:    channelID,
+							LocalBalance: 100,
+							RemoteNode:   lightning.Node{PubKey: pubKeyC},
+						}, nil
+					},
+					SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+						hop = lastHopPubKey
+						ppm = maxFee
+						payments++
+						if payments <= tt.failures {
+							return lightning.Payment{}, errors.New("payment failed")
+						}
+						return lightning.Payment{Fee: 1}, nil
+					},
+				},
+				f: f,
+			}
+			got, err := r.RebalancePair(tt.args.ctx, tt.args.out, tt.args.in, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Raiju.RebalancePair() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Moved != tt.want || got.Fees != tt.wantFee {
+				t.Errorf("Raiju.RebalancePair() = %v, %v, want %v, %v", got.Moved, got.Fees, tt.want, tt.wantFee)
+			}
+			if len(got.Attempts) != tt.wantAttempts {
+				t.Errorf("Raiju.RebalancePair() attempts = %v, want %v", len(got.Attempts), tt.wantAttempts)
+			}
+			if hop != tt.wantHop || ppm != tt.wantPPM {
+				t.Errorf("Raiju.RebalancePair() paid through %v at %v, want %v at %v", hop, ppm, tt.wantHop, tt.wantPPM)
+			}
+		})
+	}
+}
+
+func TestRaiju_RebalancePair_multiPart(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	var amounts []lightning.Satoshi
+	var parts []uint32
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				amounts = append(amounts, amount)
+				return lightning.Invoice(""), nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				return lightning.Channel{
+					Edge:         lightning.Edge{Capacity: 1000},
+					ChannelID:    channelID,
+					LocalBalance: 1000,
+					RemoteNode:   lightning.Node{PubKey: pubKeyC},
+				}, nil
+			},
+			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+				parts = append(parts, maxParts)
+				// only half the amount can make it through at once
+				if amounts[len(amounts)-1] > 50 {
+					return lightning.Payment{}, errors.New("payment failed")
+				}
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	got, err := r.RebalancePair(context.Background(), 1, 2, RebalanceRequest{MaxPercent: 10, MaxParts: 4})
+	if err != nil {
+		t.Fatalf("Raiju.RebalancePair() error = %v", err)
+	}
+	if got.Moved != 100 {
+		t.Errorf("Raiju.RebalancePair() = %v, want 100", got.Moved)
+	}
+
+	// the whole amount is tried at once, then halved on failure
+	wantAmounts := []lightning.Satoshi{100, 50, 50}
+	if !reflect.DeepEqual(amounts, wantAmounts) {
+		t.Errorf("Raiju.RebalancePair() amounts = %v, want %v", amounts, wantAmounts)
+	}
+	if !reflect.DeepEqual(parts, []uint32{4, 4, 4}) {
+		t.Errorf("Raiju.RebalancePair() max parts = %v, want 4 for every payment", parts)
+	}
+}
+
+func TestRaiju_RebalancePair_invoices(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	var invoices []lightning.Invoice
+	var canceled []lightning.Invoice
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				if want := lightning.PaymentTimeout + invoiceExpirySlack; memo != "raiju rebalance 1:2" || expiry != want {
+					t.Errorf("Raiju.RebalancePair() invoice memo %q expiring in %v, want raiju rebalance 1:2 in %v", memo, expiry, want)
+				}
+				invoice := lightning.Invoice(fmt.Sprintf("invoice%d", len(invoices)))
+				invoices = append(invoices, invoice)
+				return invoice, nil
+			},
+			CancelInvoiceFunc: func(ctx context.Context, invoice lightning.Invoice) error {
+				canceled = append(canceled, invoice)
+				return nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				return lightning.Channel{
+					Edge:         lightning.Edge{Capacity: 1000},
+					ChannelID:    channelID,
+					LocalBalance: 1000,
+					RemoteNode:   lightning.Node{PubKey: pubKeyC},
+				}, nil
+			},
+			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+				if invoice == "invoice0" {
+					return lightning.Payment{}, errors.New("payment failed")
+				}
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	if _, err := r.RebalancePair(context.Background(), 1, 2, RebalanceRequest{Amount: 10}); err != nil {
+		t.Fatalf("Raiju.RebalancePair() error = %v", err)
+	}
+
+	// only the failed payment's invoice is left to cancel
+	if want := []lightning.Invoice{"invoice0"}; !reflect.DeepEqual(canceled, want) {
+		t.Errorf("Raiju.RebalancePair() canceled = %v, want %v", canceled, want)
+	}
+}
+
+func TestRaiju_Rebalance_concurrent(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	channels := lightning.Channels{
+		{Edge: lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyB}, ChannelID: 1, LocalBalance: 900, LocalFee: 5},
+		{Edge: lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyC}, ChannelID: 2, LocalBalance: 900, LocalFee: 5},
+		{Edge: lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyD}, ChannelID: 3, LocalBalance: 100, LocalFee: 500},
+		{Edge: lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: pubKeyE}, ChannelID: 4, LocalBalance: 100, LocalFee: 500},
+	}
+
+	var mu sync.Mutex
+	inFlight := map[interface{}]bool{}
+	running, maxRunning := 0, 0
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				return lightning.Invoice(""), nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				return channels[channelID-1], nil
+			},
+			GetInfoFunc: func(ctx context.Context) (*lightning.Info, error) {
+				return &lightning.Info{PubKey: pubKey}, nil
+			},
+			ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
+				return channels, nil
+			},
+			SendPaymentFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, lastHopPubKey lightning.PubKey, maxFee lightning.FeePPM, maxParts uint32) (lightning.Payment, error) {
+				mu.Lock()
+				if inFlight[outChannelID] || inFlight[lastHopPubKey] {
+					t.Errorf("channel %d or %s used by two pairs at once", outChannelID, lastHopPubKey)
+				}
+				inFlight[outChannelID], inFlight[lastHopPubKey] = true, true
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				inFlight[outChannelID], inFlight[lastHopPubKey] = false, false
+				running--
+				mu.Unlock()
+
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	results, err := r.Rebalance(context.Background(), RebalanceRequest{MaxPercent: 20, Concurrency: 2, FeeBudget: 3})
+	if err != nil {
+		t.Fatalf("Raiju.Rebalance() error = %v", err)
+	}
+
+	if maxRunning != 2 {
+		t.Errorf("Raiju.Rebalance() ran %d payments at once, want 2", maxRunning)
+	}
+
+	// every 50 sat payment reserves a 1 sat max fee
+	total := results.Total()
+	if len(total.Attempts) != 3 || total.Fees != 3 || total.Moved != 150 {
+		t.Errorf("Raiju.Rebalance() attempts = %d moving %d sats for %d sats, want 3 moving 150 within the budget", len(total.Attempts), total.Moved, total.Fees)
+	}
+	if ppm := total.EffectivePPM(); ppm != 20_000 {
+		t.Errorf("RebalanceResult.EffectivePPM() = %v, want 20000", ppm)
+	}
+}
+
+func TestRaiju_PreviewFees(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	setFees := 0
+	r := Raiju{
+		l: &lightningerMock{
+			ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
+				return lightning.Channels{
+					{Edge: lightning.Edge{Capacity: 10}, ChannelID: 1, LocalBalance: 1, LocalFee: 5},
+					{Edge: lightning.Edge{Capacity: 10}, ChannelID: 2, LocalBalance: 5, LocalFee: 50},
+					{Edge: lightning.Edge{Capacity: 10}, ChannelID: 3, LocalBalance: 9, LocalFee: 500, Private: true},
+				}, nil
+			},
+			SetFeesFunc: func(ctx context.Context, channelID lightning.ChannelID, fee lightning.FeePPM, maxHtlc lightning.MilliSatoshi) error {
+				setFees++
+				return nil
+			},
+		},
+		f: f,
+	}
+
+	want := map[lightning.ChannelID]lightning.FeePPM{1: 500}
+	got, err := r.PreviewFees(context.Background())
+	if err != nil {
+		t.Fatalf("Raiju.PreviewFees() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Raiju.PreviewFees() = %v, want %v", got, want)
+	}
+	if setFees != 0 {
+		t.Errorf("Raiju.PreviewFees() set %d fees, want none", setFees)
+	}
+}
+
 func TestRaiju_Fees(t *testing.T) {
 	type fields struct {
 		l lightninger
@@ -899,6 +1835,7 @@ func TestNew(t *testing.T) {
 					Thresholds: []float64{80, 20},
 					Fees:       []lightning.FeePPM{},
 				},
+				d: &net.Dialer{Timeout: probeTimeout},
 			},
 		},
 	}
@@ -910,3 +1847,82 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func Test_median(t *testing.T) {
+	tests := []struct {
+		name string
+		fees []lightning.FeePPM
+		want lightning.FeePPM
+	}{
+		{
+			name: "odd number of fees",
+			fees: []lightning.FeePPM{500, 1, 10},
+			want: 10,
+		},
+		{
+			name: "even number of fees",
+			fees: []lightning.FeePPM{100, 1, 10, 1000},
+			want: 55,
+		},
+		{
+			name: "no fees",
+			fees: []lightning.FeePPM{},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.fees); got != tt.want {
+				t.Errorf("median() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// integer sizes round down
+	if got := median([]lightning.Satoshi{1, 2}); got != 1 {
+		t.Errorf("median() = %v, want 1", got)
+	}
+}
+
+func TestRaiju_Invoices(t *testing.T) {
+	settled := []lightning.SettledInvoice{
+		{Memo: "coffee", Amount: 10},
+		{Memo: RebalanceMemo + " 1:2", Amount: 100},
+		{Memo: "", Amount: 5},
+	}
+
+	tests := []struct {
+		name       string
+		rebalances bool
+		want       []lightning.SettledInvoice
+	}{
+		{
+			name: "rebalances are left out",
+			want: []lightning.SettledInvoice{settled[0], settled[2]},
+		},
+		{
+			name:       "rebalances are included if asked for",
+			rebalances: true,
+			want:       settled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Raiju{
+				l: &lightningerMock{
+					SettledInvoicesFunc: func(ctx context.Context, since time.Time) ([]lightning.SettledInvoice, error) {
+						return settled, nil
+					},
+				},
+			}
+
+			got, err := r.Invoices(context.Background(), time.Now(), tt.rebalances)
+			if err != nil {
+				t.Fatalf("Raiju.Invoices() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Raiju.Invoices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
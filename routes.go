@@ -0,0 +1,180 @@
+package raiju
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+const (
+	// routeCandidates of circular paths tried per payment
+	routeCandidates = 3
+	// maxRouteHops of a circular path, including the hop back to the local node
+	maxRouteHops = 6
+	// capacityWeight in ppm of sending a channel's whole capacity, so paths lean on channels with room for the amount
+	capacityWeight = 1000.0
+)
+
+// arc of an edge in the direction a payment is forwarded.
+type arc struct {
+	to lightning.PubKey
+	// fee of forwarding the amount, base fee included
+	fee      lightning.FeePPM
+	capacity lightning.Satoshi
+}
+
+// pathNode on the search frontier.
+type pathNode struct {
+	pubKey lightning.PubKey
+	weight float64
+	fee    lightning.FeePPM
+	hops   int
+}
+
+// pathHeap of the lightest nodes on the frontier.
+type pathHeap []pathNode
+
+func (h pathHeap) Len() int            { return len(h) }
+func (h pathHeap) Less(i, j int) bool  { return h[i].weight < h[j].weight }
+func (h pathHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pathHeap) Push(x interface{}) { *h = append(*h, x.(pathNode)) }
+func (h *pathHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// arcs of the graph which can forward amount, skipping any through the local node except the one back in from the
+// last hop.
+func arcs(graph *lightning.Graph, local lightning.PubKey, last lightning.PubKey, amount lightning.Satoshi) map[lightning.PubKey][]arc {
+	out := make(map[lightning.PubKey][]arc)
+	add := func(from lightning.PubKey, to lightning.PubKey, policy *lightning.RoutingPolicy, capacity lightning.Satoshi) {
+		if policy == nil || policy.Disabled || capacity < amount || from == local {
+			return
+		}
+		if to == local && from != last {
+			return
+		}
+		out[from] = append(out[from], arc{to: to, fee: policy.Fee(amount), capacity: capacity})
+	}
+
+	for _, e := range graph.Edges {
+		add(e.Node1, e.Node2, e.Node1Policy, e.Capacity)
+		add(e.Node2, e.Node1, e.Node2Policy, e.Capacity)
+	}
+
+	return out
+}
+
+// circularPaths from the out channel's peer back to the local node through the last hop, lightest first.
+//
+// Paths are weighed by the fees of the forwarding nodes, base fees included as a rate of the amount, plus how much of
+// each channel's capacity the amount takes up. Only paths under the max fee are returned, and each one avoids the
+// nodes in between of the lighter ones so a single bad node can't fail them all. Paths are the hops after the local node, ending back at it.
+func circularPaths(graph *lightning.Graph, local lightning.PubKey, first lightning.PubKey, last lightning.PubKey, amount lightning.Satoshi, maxFee lightning.FeePPM) [][]lightning.PubKey {
+	forward := arcs(graph, local, last, amount)
+	excluded := make(map[lightning.PubKey]bool)
+
+	var paths [][]lightning.PubKey
+	for len(paths) < routeCandidates {
+		path := lightestPath(forward, excluded, first, local, amount, maxFee)
+		if path == nil {
+			break
+		}
+		paths = append(paths, path)
+
+		// the in between nodes, the first and last hops are fixed by the channels
+		if len(path) <= 3 {
+			break
+		}
+		for _, n := range path[1 : len(path)-2] {
+			excluded[n] = true
+		}
+	}
+
+	return paths
+}
+
+// lightestPath from the source to the target over the forward arcs, nil if none is under the max fee.
+func lightestPath(forward map[lightning.PubKey][]arc, excluded map[lightning.PubKey]bool, source lightning.PubKey, target lightning.PubKey, amount lightning.Satoshi, maxFee lightning.FeePPM) []lightning.PubKey {
+	weights := map[lightning.PubKey]float64{source: 0}
+	previous := make(map[lightning.PubKey]lightning.PubKey)
+	done := make(map[lightning.PubKey]bool)
+
+	h := &pathHeap{{pubKey: source}}
+	for h.Len() > 0 {
+		n := heap.Pop(h).(pathNode)
+		if done[n.pubKey] {
+			continue
+		}
+		done[n.pubKey] = true
+
+		if n.pubKey == target {
+			path := []lightning.PubKey{target}
+			for p := target; p != source; {
+				p = previous[p]
+				path = append([]lightning.PubKey{p}, path...)
+			}
+			return path
+		}
+
+		for _, a := range forward[n.pubKey] {
+			if excluded[a.to] || done[a.to] {
+				continue
+			}
+
+			fee := n.fee + a.fee
+			if fee > maxFee || n.hops+1 > maxRouteHops {
+				continue
+			}
+
+			w := n.weight + float64(a.fee) + capacityWeight*float64(amount)/float64(a.capacity)
+			if best, ok := weights[a.to]; ok && best <= w {
+				continue
+			}
+			weights[a.to] = w
+			previous[a.to] = n.pubKey
+			heap.Push(h, pathNode{pubKey: a.to, weight: w, fee: fee, hops: n.hops + 1})
+		}
+	}
+
+	return nil
+}
+
+// payCircular pays the invoice over the lightest circular paths through the graph, until one goes through.
+func (r Raiju) payCircular(ctx context.Context, graph *lightning.Graph, invoice lightning.Invoice, out lightning.Channel, lastHopPubKey lightning.PubKey, amount lightning.Satoshi, maxFee lightning.FeePPM) (lightning.Payment, error) {
+	local := out.Node1
+	if local == out.RemoteNode.PubKey {
+		local = out.Node2
+	}
+
+	paths := circularPaths(graph, local, out.RemoteNode.PubKey, lastHopPubKey, amount, maxFee)
+	if len(paths) == 0 {
+		return lightning.Payment{}, errors.New("no circular path under the max fee")
+	}
+
+	var payment lightning.Payment
+	var err error
+	for _, path := range paths {
+		var route lightning.Route
+		route, err = r.l.BuildRoute(ctx, invoice, out.ChannelID, path)
+		if err != nil {
+			continue
+		}
+		// the graph's fees are only an estimate of the compounded fees, so double check what lnd came up with
+		if float64(route.Fee) > float64(amount.Millis())*maxFee.Rate() {
+			err = errors.New("route fee over the max fee")
+			continue
+		}
+
+		payment, err = r.l.SendToRoute(ctx, invoice, route)
+		if err == nil {
+			return payment, nil
+		}
+	}
+
+	return payment, err
+}
@@ -0,0 +1,146 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+// routesGraph with a cheap path through B, a pricey one through C, and a cheaper one through E which is too small.
+func routesGraph() *lightning.Graph {
+	policy := func(fee lightning.FeePPM) *lightning.RoutingPolicy {
+		return &lightning.RoutingPolicy{FeeRate: fee}
+	}
+
+	return &lightning.Graph{
+		Edges: []lightning.Edge{
+			{Node1: pubKey, Node2: pubKeyA, Capacity: 1000, Node1Policy: policy(0), Node2Policy: policy(0)},
+			{Node1: pubKeyA, Node2: pubKeyB, Capacity: 1000, Node1Policy: policy(10)},
+			{Node1: pubKeyB, Node2: pubKeyD, Capacity: 1000, Node1Policy: policy(10)},
+			{Node1: pubKeyA, Node2: pubKeyC, Capacity: 1000, Node1Policy: policy(5)},
+			{Node1: pubKeyC, Node2: pubKeyD, Capacity: 1000, Node1Policy: policy(100)},
+			{Node1: pubKeyA, Node2: pubKeyE, Capacity: 10, Node1Policy: policy(1)},
+			{Node1: pubKeyE, Node2: pubKeyD, Capacity: 10, Node1Policy: policy(1)},
+			{Node1: pubKeyD, Node2: pubKey, Capacity: 1000, Node1Policy: policy(1)},
+		},
+	}
+}
+
+func Test_circularPaths(t *testing.T) {
+	// B charges a base fee which outweighs its low rate for the amount
+	baseFee := routesGraph()
+	baseFee.Edges[2].Node1Policy.BaseFee = 10
+
+	tests := []struct {
+		name   string
+		graph  *lightning.Graph
+		maxFee lightning.FeePPM
+		last   lightning.PubKey
+		want   [][]lightning.PubKey
+	}{
+		{
+			name:   "lightest first avoiding earlier nodes",
+			maxFee: 500,
+			last:   pubKeyD,
+			want: [][]lightning.PubKey{
+				{pubKeyA, pubKeyB, pubKeyD, pubKey},
+				{pubKeyA, pubKeyC, pubKeyD, pubKey},
+			},
+		},
+		{
+			name:   "max fee rules out the pricey path",
+			maxFee: 50,
+			last:   pubKeyD,
+			want: [][]lightning.PubKey{
+				{pubKeyA, pubKeyB, pubKeyD, pubKey},
+			},
+		},
+		{
+			name:   "base fees count towards the weight",
+			graph:  baseFee,
+			maxFee: 500,
+			last:   pubKeyD,
+			want: [][]lightning.PubKey{
+				{pubKeyA, pubKeyC, pubKeyD, pubKey},
+				{pubKeyA, pubKeyB, pubKeyD, pubKey},
+			},
+		},
+		{
+			name:   "only back in through the last hop",
+			maxFee: 500,
+			last:   pubKeyB,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := tt.graph
+			if graph == nil {
+				graph = routesGraph()
+			}
+			if got := circularPaths(graph, pubKey, pubKeyA, tt.last, 100, tt.maxFee); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("circularPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaiju_RebalancePair_routes(t *testing.T) {
+	f, _ := NewLiquidityFees([]float64{80, 20}, []lightning.FeePPM{5, 50, 500}, 0)
+
+	var built [][]lightning.PubKey
+	r := Raiju{
+		l: &lightningerMock{
+			AddInvoiceFunc: func(ctx context.Context, amount lightning.Satoshi, memo string, expiry time.Duration) (lightning.Invoice, error) {
+				return lightning.Invoice(""), nil
+			},
+			DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+				return routesGraph(), nil
+			},
+			GetChannelFunc: func(ctx context.Context, channelID lightning.ChannelID) (lightning.Channel, error) {
+				remote := pubKeyA
+				if channelID == 2 {
+					remote = pubKeyD
+				}
+				return lightning.Channel{
+					Edge:         lightning.Edge{Capacity: 1000, Node1: pubKey, Node2: remote},
+					ChannelID:    channelID,
+					LocalBalance: 1000,
+					RemoteNode:   lightning.Node{PubKey: remote},
+				}, nil
+			},
+			BuildRouteFunc: func(ctx context.Context, invoice lightning.Invoice, outChannelID lightning.ChannelID, hops []lightning.PubKey) (lightning.Route, error) {
+				built = append(built, hops)
+				return lightning.Route{Amount: lightning.Satoshi(10).Millis(), Fee: 1}, nil
+			},
+			SendToRouteFunc: func(ctx context.Context, invoice lightning.Invoice, route lightning.Route) (lightning.Payment, error) {
+				// the first path is down
+				if len(built) == 1 {
+					return lightning.Payment{FailureCode: "TEMPORARY_CHANNEL_FAILURE"}, errors.New("payment failed")
+				}
+				return lightning.Payment{Fee: 1}, nil
+			},
+		},
+		f: f,
+	}
+
+	got, err := r.RebalancePair(context.Background(), 1, 2, RebalanceRequest{Amount: 10, Routes: true})
+	if err != nil {
+		t.Fatalf("Raiju.RebalancePair() error = %v", err)
+	}
+	if got.Moved != 10 || len(got.Attempts) != 1 || !got.Attempts[0].Succeeded {
+		t.Errorf("Raiju.RebalancePair() = %v with attempts %v, want 10 in one successful attempt", got.Moved, got.Attempts)
+	}
+
+	want := [][]lightning.PubKey{
+		{pubKeyA, pubKeyB, pubKeyD, pubKey},
+		{pubKeyA, pubKeyC, pubKeyD, pubKey},
+	}
+	if !reflect.DeepEqual(built, want) {
+		t.Errorf("Raiju.RebalancePair() built routes = %v, want %v", built, want)
+	}
+}
@@ -0,0 +1,225 @@
+package raiju
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Scorer ranks candidate nodes.
+type Scorer interface {
+	// Score each node, a higher score is a better candidate.
+	Score(nodes []RelativeNode) []float64
+}
+
+// DistanceScorer ranks by distance, then distant neighbors, capacity, and channels.
+type DistanceScorer struct{}
+
+// Score nodes by their position in distance order, nodes which are equally distant share a score.
+func (DistanceScorer) Score(nodes []RelativeNode) []float64 {
+	s := sortDistance(nodes)
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return s.Less(order[i], order[j])
+	})
+
+	scores := make([]float64, len(nodes))
+	for i := 1; i < len(order); i++ {
+		scores[order[i]] = scores[order[i-1]]
+		if s.Less(order[i-1], order[i]) {
+			scores[order[i]]++
+		}
+	}
+
+	return scores
+}
+
+// CapacityScorer ranks by distance weighted by the log of capacity, so big nodes are favored but distance still matters.
+type CapacityScorer struct{}
+
+// Score nodes by distance times log capacity.
+func (CapacityScorer) Score(nodes []RelativeNode) []float64 {
+	scores := make([]float64, len(nodes))
+	for i, n := range nodes {
+		scores[i] = float64(n.Distance) * math.Log1p(float64(n.Capacity))
+	}
+
+	return scores
+}
+
+// CentralityScorer ranks by the betweenness centrality gain for the root node, requires centrality sampling.
+type CentralityScorer struct{}
+
+// Score nodes by centrality gain.
+func (CentralityScorer) Score(nodes []RelativeNode) []float64 {
+	scores := make([]float64, len(nodes))
+	for i, n := range nodes {
+		scores[i] = n.Centrality
+	}
+
+	return scores
+}
+
+// announcementHorizon is how long a node announcement is kept in the graph without an update.
+const announcementHorizon = 14 * 24 * time.Hour
+
+// ReliabilityScorer ranks by a node's uptime observed by probing.
+//
+// Nodes which have never been probed fall back to how recently they updated their announcement,
+// the freshest announcement is treated as 100% and one at the graph's pruning horizon as 0%.
+type ReliabilityScorer struct{}
+
+// Score nodes by uptime percent, or announcement freshness if never probed.
+func (ReliabilityScorer) Score(nodes []RelativeNode) []float64 {
+	var freshest time.Time
+	for _, n := range nodes {
+		if n.Updated.After(freshest) {
+			freshest = n.Updated
+		}
+	}
+
+	scores := make([]float64, len(nodes))
+	for i, n := range nodes {
+		if n.Probed {
+			scores[i] = n.Uptime
+			continue
+		}
+
+		age := freshest.Sub(n.Updated)
+		scores[i] = math.Max(0, 100*(1-float64(age)/float64(announcementHorizon)))
+	}
+
+	return scores
+}
+
+// FeeScorer ranks by competitive (cheaper) outbound fees, which should attract routing through the node.
+type FeeScorer struct{}
+
+// Score nodes by negative median outbound fee.
+func (FeeScorer) Score(nodes []RelativeNode) []float64 {
+	scores := make([]float64, len(nodes))
+	for i, n := range nodes {
+		scores[i] = -float64(n.MedianOutboundFee)
+	}
+
+	return scores
+}
+
+// DiversityScorer ranks by how few of the root node's peers are hosted in the same ASN or subnet.
+type DiversityScorer struct{}
+
+// Score nodes by the negative number of peers sharing their networks.
+func (DiversityScorer) Score(nodes []RelativeNode) []float64 {
+	scores := make([]float64, len(nodes))
+	for i, n := range nodes {
+		scores[i] = -float64(n.PeersInASN + n.PeersInSubnet)
+	}
+
+	return scores
+}
+
+// Weight of a scorer in a composite.
+type Weight struct {
+	Scorer Scorer
+	Weight float64
+}
+
+// WeightedScorer is a composite of scorers.
+//
+// Each scorer's scores are normalized to the range [0, 1] before being weighted, so scorers with large values
+// (e.g. capacity) don't drown out the others.
+type WeightedScorer []Weight
+
+// Score nodes by the sum of weighted and normalized scores.
+func (ws WeightedScorer) Score(nodes []RelativeNode) []float64 {
+	scores := make([]float64, len(nodes))
+	for _, w := range ws {
+		s := w.Scorer.Score(nodes)
+
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, v := range s {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+
+		// all the same score, so no influence on the ranking
+		if high == low {
+			continue
+		}
+
+		for i, v := range s {
+			scores[i] += w.Weight * (v - low) / (high - low)
+		}
+	}
+
+	return scores
+}
+
+// scorers available by name.
+var scorers = map[string]Scorer{
+	"distance":    DistanceScorer{},
+	"capacity":    CapacityScorer{},
+	"centrality":  CentralityScorer{},
+	"reliability": ReliabilityScorer{},
+	"fees":        FeeScorer{},
+	"diversity":   DiversityScorer{},
+}
+
+// NewScorer from scorer names and their weights, multiple scorers are combined into a weighted composite.
+func NewScorer(names []string, weights []float64) (Scorer, error) {
+	if len(names) == 0 {
+		return nil, errors.New("at least one scorer is required")
+	}
+
+	if len(names) != len(weights) {
+		return nil, errors.New("each scorer must have a weight")
+	}
+
+	ws := make(WeightedScorer, len(names))
+	for i, name := range names {
+		s, ok := scorers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scorer: %s", name)
+		}
+
+		if weights[i] < 0 {
+			return nil, errors.New("scorer weights must be positive")
+		}
+
+		ws[i] = Weight{
+			Scorer: s,
+			Weight: weights[i],
+		}
+	}
+
+	// no need to normalize a single scorer
+	if len(ws) == 1 {
+		return ws[0].Scorer, nil
+	}
+
+	return ws, nil
+}
+
+// rank nodes by descending score.
+func rank(nodes []RelativeNode, scorer Scorer) []RelativeNode {
+	scores := scorer.Score(nodes)
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	ranked := make([]RelativeNode, len(nodes))
+	for i, o := range order {
+		ranked[i] = nodes[o]
+	}
+
+	return ranked
+}
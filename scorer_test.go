@@ -0,0 +1,217 @@
+package raiju
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestDistanceScorer_Score(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []RelativeNode
+		want  []float64
+	}{
+		{
+			name: "further nodes score higher",
+			nodes: []RelativeNode{
+				{Distance: 3},
+				{Distance: 2},
+				{Distance: 2, DistantNeigbors: 1},
+			},
+			want: []float64{2, 0, 1},
+		},
+		{
+			name: "equal nodes share a score",
+			nodes: []RelativeNode{
+				{Distance: 2},
+				{Distance: 3},
+				{Distance: 2},
+			},
+			want: []float64{0, 1, 0},
+		},
+		{
+			name:  "no nodes",
+			nodes: []RelativeNode{},
+			want:  []float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (DistanceScorer{}).Score(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DistanceScorer.Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReliabilityScorer_Score(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		nodes []RelativeNode
+		want  []float64
+	}{
+		{
+			name: "probed nodes score by uptime",
+			nodes: []RelativeNode{
+				{Node: lightning.Node{Updated: now}, Uptime: 50, Probed: true},
+				{Node: lightning.Node{Updated: now.Add(-time.Hour)}, Uptime: 90, Probed: true},
+			},
+			want: []float64{50, 90},
+		},
+		{
+			name: "unprobed nodes fall back to announcement freshness",
+			nodes: []RelativeNode{
+				{Node: lightning.Node{Updated: now}},
+				{Node: lightning.Node{Updated: now.Add(-7 * 24 * time.Hour)}},
+				{Node: lightning.Node{Updated: now.Add(-30 * 24 * time.Hour)}},
+				{Node: lightning.Node{Updated: now.Add(-7 * 24 * time.Hour)}, Uptime: 80, Probed: true},
+			},
+			want: []float64{100, 50, 0, 80},
+		},
+		{
+			name:  "no nodes",
+			nodes: []RelativeNode{},
+			want:  []float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ReliabilityScorer{}).Score(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReliabilityScorer.Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedScorer_Score(t *testing.T) {
+	nodes := []RelativeNode{
+		{Distance: 2, Centrality: 10, MedianOutboundFee: 100},
+		{Distance: 3, Centrality: 0, MedianOutboundFee: 100},
+		{Distance: 4, Centrality: 5, MedianOutboundFee: 100},
+	}
+
+	tests := []struct {
+		name string
+		ws   WeightedScorer
+		want []float64
+	}{
+		{
+			name: "scores are normalized before weighting",
+			ws: WeightedScorer{
+				{Scorer: DistanceScorer{}, Weight: 1},
+				{Scorer: CentralityScorer{}, Weight: 2},
+			},
+			want: []float64{2, 0.5, 2},
+		},
+		{
+			name: "identical scores have no influence",
+			ws: WeightedScorer{
+				{Scorer: FeeScorer{}, Weight: 10},
+				{Scorer: CentralityScorer{}, Weight: 1},
+			},
+			want: []float64{1, 0, 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ws.Score(nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WeightedScorer.Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewScorer(t *testing.T) {
+	type args struct {
+		names   []string
+		weights []float64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Scorer
+		wantErr bool
+	}{
+		{
+			name: "single scorer is not wrapped",
+			args: args{
+				names:   []string{"fees"},
+				weights: []float64{1},
+			},
+			want:    FeeScorer{},
+			wantErr: false,
+		},
+		{
+			name: "multiple scorers are combined",
+			args: args{
+				names:   []string{"distance", "reliability"},
+				weights: []float64{2, 1},
+			},
+			want: WeightedScorer{
+				{Scorer: DistanceScorer{}, Weight: 2},
+				{Scorer: ReliabilityScorer{}, Weight: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown scorer",
+			args: args{
+				names:   []string{"vibes"},
+				weights: []float64{1},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "negative weight",
+			args: args{
+				names:   []string{"distance", "capacity"},
+				weights: []float64{1, -1},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "no scorers",
+			args: args{
+				names:   []string{},
+				weights: []float64{},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewScorer(tt.args.names, tt.args.weights)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewScorer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewScorer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rank(t *testing.T) {
+	nodes := []RelativeNode{
+		{Node: lightning.Node{PubKey: pubKeyA}, MedianOutboundFee: 100},
+		{Node: lightning.Node{PubKey: pubKeyB}, MedianOutboundFee: 10},
+		{Node: lightning.Node{PubKey: pubKeyC}, MedianOutboundFee: 1000},
+	}
+
+	got := rank(nodes, FeeScorer{})
+	want := []lightning.PubKey{pubKeyB, pubKeyA, pubKeyC}
+	for i := range want {
+		if got[i].PubKey != want[i] {
+			t.Errorf("rank() = %v, want %v", got, want)
+		}
+	}
+}
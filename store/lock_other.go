@@ -0,0 +1,15 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// lock is a no-op where advisory file locks aren't supported.
+func lock(*os.File) error {
+	return nil
+}
+
+// unlock is a no-op where advisory file locks aren't supported.
+func unlock(*os.File) error {
+	return nil
+}
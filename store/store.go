@@ -0,0 +1,85 @@
+// Persistence of raiju state between runs.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Load the JSON file at path into v, a missing file leaves v untouched.
+func Load(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Save v as JSON to the file at path.
+//
+// The file is written next to path and then renamed over it, so readers never see a partial write.
+func Save(path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Update the JSON file at path by loading it into v, calling fn to modify v, and saving v back.
+//
+// An advisory lock is held on a file next to path for the whole update, so concurrent updates from other
+// processes are applied one after another instead of overwriting each other.
+func Update(path string, v any, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	l, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	if err := lock(l); err != nil {
+		return err
+	}
+	defer unlock(l)
+
+	if err := Load(path, v); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	return Save(path, v)
+}
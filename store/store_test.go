@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	want := map[string]int{"a": 1, "b": 2}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got := make(map[string]int)
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestLoad_missing(t *testing.T) {
+	got := map[string]int{"a": 1}
+	if err := Load(filepath.Join(t.TempDir(), "missing.json"), &got); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, map[string]int{"a": 1}) {
+		t.Errorf("Load() = %v, want untouched", got)
+	}
+}
+
+func TestUpdate_concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	const updates = 20
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			got := make(map[string]int)
+			err := Update(path, &got, func() error {
+				got["count"]++
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := make(map[string]int)
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got["count"] != updates {
+		t.Errorf("Update() count = %d, want %d", got["count"], updates)
+	}
+}
+
+func TestUpdate_error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := Save(path, map[string]int{"a": 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got := make(map[string]int)
+	err := Update(path, &got, func() error {
+		got["a"] = 2
+		return errors.New("nope")
+	})
+	if err == nil {
+		t.Fatal("Update() error = nil, want error")
+	}
+
+	want := make(map[string]int)
+	if err := Load(path, &want); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want["a"] != 1 {
+		t.Errorf("Update() saved %v after an error", want)
+	}
+}
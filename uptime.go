@@ -0,0 +1,148 @@
+package raiju
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+const (
+	// probeTimeout of a single connection attempt
+	probeTimeout = 10 * time.Second
+	// probeConcurrency limits the number of nodes probed at once
+	probeConcurrency = 10
+)
+
+// Check of a node's reachability.
+type Check struct {
+	Time      time.Time
+	Reachable bool
+}
+
+// UptimeHistory of reachability checks by node.
+type UptimeHistory map[lightning.PubKey][]Check
+
+// Record the results of a probe.
+func (h UptimeHistory) Record(reachable map[lightning.PubKey]bool, at time.Time) {
+	for k, r := range reachable {
+		h[k] = append(h[k], Check{Time: at, Reachable: r})
+	}
+}
+
+// Prune checks older than before, dropping nodes with no checks left.
+func (h UptimeHistory) Prune(before time.Time) {
+	for k, checks := range h {
+		kept := checks[:0]
+		for _, c := range checks {
+			if !c.Time.Before(before) {
+				kept = append(kept, c)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(h, k)
+		} else {
+			h[k] = kept
+		}
+	}
+}
+
+// Uptime percent of each node checked since the given time.
+func (h UptimeHistory) Uptime(since time.Time) map[lightning.PubKey]float64 {
+	uptime := make(map[lightning.PubKey]float64)
+	for k, checks := range h {
+		var total, up int
+		for _, c := range checks {
+			if c.Time.Before(since) {
+				continue
+			}
+
+			total++
+			if c.Reachable {
+				up++
+			}
+		}
+
+		if total > 0 {
+			uptime[k] = float64(up) / float64(total) * 100
+		}
+	}
+
+	return uptime
+}
+
+// Probe the reachability of the local node's peers and the candidates of the request.
+//
+// A node is reachable if a TCP connection can be opened to any of its clearnet addresses. Only clearnet addresses
+// are probed, nodes without one have an unknown uptime and are left out of the results rather than marked down.
+// The request's uptime filter should be disabled, otherwise nodes it excludes are never probed again.
+func (r Raiju) Probe(ctx context.Context, request CandidatesRequest) (map[lightning.PubKey]bool, error) {
+	channels, err := r.l.ListChannels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list channels: %w", err)
+	}
+
+	candidates, err := r.Candidates(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get candidates: %w", err)
+	}
+
+	nodes := make(map[lightning.PubKey]lightning.Node)
+	for _, c := range channels {
+		nodes[c.RemoteNode.PubKey] = c.RemoteNode
+	}
+	for _, c := range candidates {
+		nodes[c.PubKey] = c.Node
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, probeConcurrency)
+	reachable := make(map[lightning.PubKey]bool)
+	for k, n := range nodes {
+		wg.Add(1)
+		go func(k lightning.PubKey, n lightning.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			up, ok := r.reachable(ctx, n)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			reachable[k] = up
+			mu.Unlock()
+		}(k, n)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return reachable, nil
+}
+
+// reachable is true if any clearnet address of the node accepts a connection, ok is false if there are none to try.
+func (r Raiju) reachable(ctx context.Context, node lightning.Node) (up bool, ok bool) {
+	for _, a := range node.Addresses {
+		// anonymity network addresses can't be dialed directly
+		if addr, err := lightning.ParseAddress(a); err != nil || !addr.Type.Clearnet() {
+			continue
+		}
+
+		ok = true
+		conn, err := r.d.DialContext(ctx, "tcp", a)
+		if err == nil {
+			conn.Close()
+			return true, true
+		}
+	}
+
+	return false, ok
+}
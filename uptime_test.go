@@ -0,0 +1,119 @@
+package raiju
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestUptimeHistory_Uptime(t *testing.T) {
+	h := UptimeHistory{
+		pubKeyA: {
+			{Time: updated.Add(-time.Hour), Reachable: false},
+			{Time: updated, Reachable: true},
+			{Time: updated.Add(time.Hour), Reachable: true},
+			{Time: updated.Add(2 * time.Hour), Reachable: true},
+		},
+		pubKeyB: {
+			{Time: updated.Add(-time.Hour), Reachable: true},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  map[lightning.PubKey]float64
+	}{
+		{
+			name:  "all checks",
+			since: updated.Add(-time.Hour),
+			want:  map[lightning.PubKey]float64{pubKeyA: 75, pubKeyB: 100},
+		},
+		{
+			name:  "nodes without recent checks are unknown",
+			since: updated,
+			want:  map[lightning.PubKey]float64{pubKeyA: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Uptime(tt.since); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UptimeHistory.Uptime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUptimeHistory_Prune(t *testing.T) {
+	h := UptimeHistory{}
+	h.Record(map[lightning.PubKey]bool{pubKeyA: true, pubKeyB: false}, updated)
+	h.Record(map[lightning.PubKey]bool{pubKeyA: false}, updated.Add(time.Hour))
+
+	h.Prune(updated.Add(time.Minute))
+
+	want := UptimeHistory{
+		pubKeyA: {{Time: updated.Add(time.Hour), Reachable: false}},
+	}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("UptimeHistory.Prune() = %v, want %v", h, want)
+	}
+}
+
+func TestRaiju_Probe(t *testing.T) {
+	const downAddress = "44.127.188.137:9735"
+
+	l := &lightningerMock{
+		ListChannelsFunc: func(ctx context.Context) (lightning.Channels, error) {
+			return lightning.Channels{
+				{RemoteNode: lightning.Node{PubKey: pubKeyB, Addresses: []string{downAddress, clearnetAddress}}},
+				{RemoteNode: lightning.Node{PubKey: pubKeyE, Addresses: []string{torAddress}}},
+			}, nil
+		},
+		DescribeGraphFunc: func(ctx context.Context) (*lightning.Graph, error) {
+			// a linear network (A) <=> (B) <=> (C) <=> (D)
+			g := lineGraph(pubKeyA, pubKeyB, pubKeyC, pubKeyD)
+			g.Nodes[3].Addresses = []string{downAddress}
+			return g, nil
+		},
+	}
+	d := &dialerMock{
+		DialContextFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if address == downAddress {
+				return nil, errors.New("connection refused")
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		},
+	}
+
+	r := Raiju{
+		l: l,
+		d: d,
+	}
+	got, err := r.Probe(context.Background(), CandidatesRequest{
+		PubKeys:     []lightning.PubKey{pubKeyA},
+		MinCapacity: 1,
+		MinChannels: 1,
+		MinDistance: 2,
+		MinUpdated:  updated.Add(time.Hour * -3),
+		Limit:       10,
+	})
+	if err != nil {
+		t.Fatalf("Raiju.Probe() error = %v", err)
+	}
+
+	// tor only E can't be probed
+	want := map[lightning.PubKey]bool{
+		pubKeyB: true,
+		pubKeyC: true,
+		pubKeyD: false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Raiju.Probe() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,615 @@
+package view
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nyonson/raiju"
+	"github.com/nyonson/raiju/lightning"
+	"github.com/rodaine/table"
+)
+
+// Format of command output.
+type Format string
+
+const (
+	// Table for humans.
+	Table Format = "table"
+	// JSON array of records.
+	JSON Format = "json"
+	// JSONLines with one record per line.
+	JSONLines Format = "jsonl"
+	// CSV with a header row.
+	CSV Format = "csv"
+)
+
+// ParseFormat from its name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Table, JSON, JSONLines, CSV:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown output format: %s", s)
+}
+
+// Printer writes command results in a format.
+type Printer struct {
+	w      io.Writer
+	format Format
+}
+
+// NewPrinter for format to w.
+func NewPrinter(w io.Writer, format Format) Printer {
+	return Printer{
+		w:      w,
+		format: format,
+	}
+}
+
+// Format the printer writes.
+func (p Printer) Format() Format {
+	return p.format
+}
+
+type nodeRecord struct {
+	PubKey            lightning.PubKey  `json:"pubkey"`
+	Alias             string            `json:"alias"`
+	Root              lightning.PubKey  `json:"root"`
+	Distance          int64             `json:"distance"`
+	DistantNeighbors  int64             `json:"distant_neighbors"`
+	Capacity          lightning.Satoshi `json:"capacity_sat"`
+	Channels          int64             `json:"channels"`
+	Centrality        float64           `json:"centrality"`
+	MedianOutboundFee lightning.FeePPM  `json:"median_outbound_fee_ppm"`
+	AvgOutboundFee    lightning.FeePPM  `json:"avg_outbound_fee_ppm"`
+	MedianInboundFee  lightning.FeePPM  `json:"median_inbound_fee_ppm"`
+	AvgInboundFee     lightning.FeePPM  `json:"avg_inbound_fee_ppm"`
+	MedianChannelSize lightning.Satoshi `json:"median_channel_size_sat"`
+	DisabledChannels  int64             `json:"disabled_channels"`
+	OldestChannelAge  int64             `json:"oldest_channel_age_blocks"`
+	PeersInASN        int64             `json:"peers_in_asn"`
+	PeersInSubnet     int64             `json:"peers_in_subnet"`
+	Updated           time.Time         `json:"updated"`
+	Addresses         []string          `json:"addresses"`
+}
+
+// Nodes ranked as candidates.
+func (p Printer) Nodes(nodes []raiju.RelativeNode) error {
+	// only worth a column if the candidates came from multiple roots or share hosting with the roots' peers
+	roots := make(map[lightning.PubKey]bool)
+	var shared bool
+	for _, v := range nodes {
+		roots[v.Root] = true
+		shared = shared || v.PeersInASN > 0 || v.PeersInSubnet > 0
+	}
+
+	header := []interface{}{"Pubkey", "Alias", "Distance", "Distant Neighbors", "Capacity (BTC)", "Channels", "Centrality", "Out Fee PPM", "In Fee PPM", "Median Channel (BTC)", "Disabled", "Oldest (Blocks)", "Updated", "Addresses"}
+	if len(roots) > 1 {
+		header = append(header, "Root")
+	}
+	if shared {
+		header = append(header, "Peers in ASN", "Peers in Subnet")
+	}
+
+	rows := make([][]interface{}, len(nodes))
+	records := make([]nodeRecord, len(nodes))
+	for i, v := range nodes {
+		rows[i] = []interface{}{v.PubKey, v.Alias, v.Distance, v.DistantNeigbors, v.Capacity.BTC(), v.Channels, v.Centrality, v.MedianOutboundFee, v.MedianInboundFee, v.MedianChannelSize.BTC(), v.DisabledChannels, v.OldestChannelAge, v.Updated, v.Addresses}
+		if len(roots) > 1 {
+			rows[i] = append(rows[i], v.Root)
+		}
+		if shared {
+			rows[i] = append(rows[i], v.PeersInASN, v.PeersInSubnet)
+		}
+
+		records[i] = nodeRecord{
+			PubKey:            v.PubKey,
+			Alias:             v.Alias,
+			Root:              v.Root,
+			Distance:          v.Distance,
+			DistantNeighbors:  v.DistantNeigbors,
+			Capacity:          v.Capacity,
+			Channels:          v.Channels,
+			Centrality:        v.Centrality,
+			MedianOutboundFee: v.MedianOutboundFee,
+			AvgOutboundFee:    v.AvgOutboundFee,
+			MedianInboundFee:  v.MedianInboundFee,
+			AvgInboundFee:     v.AvgInboundFee,
+			MedianChannelSize: v.MedianChannelSize,
+			DisabledChannels:  v.DisabledChannels,
+			OldestChannelAge:  v.OldestChannelAge,
+			PeersInASN:        v.PeersInASN,
+			PeersInSubnet:     v.PeersInSubnet,
+			Updated:           v.Updated,
+			Addresses:         v.Addresses,
+		}
+	}
+
+	return output(p, header, rows, records)
+}
+
+type plannedChannelRecord struct {
+	PubKey           lightning.PubKey  `json:"pubkey"`
+	Alias            string            `json:"alias"`
+	Root             lightning.PubKey  `json:"root"`
+	Distance         int64             `json:"distance"`
+	DistantNeighbors int64             `json:"distant_neighbors"`
+	Capacity         lightning.Satoshi `json:"capacity_sat"`
+	Channels         int64             `json:"channels"`
+	Amount           lightning.Satoshi `json:"amount_sat"`
+}
+
+// Plan of channels, with the total and resulting mean distance for humans.
+func (p Printer) Plan(plan raiju.Plan) error {
+	header := []interface{}{"Pubkey", "Alias", "Distance", "Distant Neighbors", "Capacity (BTC)", "Channels", "Amount (BTC)"}
+
+	rows := make([][]interface{}, len(plan.Channels))
+	records := make([]plannedChannelRecord, len(plan.Channels))
+	for i, c := range plan.Channels {
+		rows[i] = []interface{}{c.PubKey, c.Alias, c.Distance, c.DistantNeigbors, c.Capacity.BTC(), c.Channels, c.Amount.BTC()}
+		records[i] = plannedChannelRecord{
+			PubKey:           c.PubKey,
+			Alias:            c.Alias,
+			Root:             c.Root,
+			Distance:         c.Distance,
+			DistantNeighbors: c.DistantNeigbors,
+			Capacity:         c.Capacity,
+			Channels:         c.Channels,
+			Amount:           c.Amount,
+		}
+	}
+
+	if err := output(p, header, rows, records); err != nil {
+		return err
+	}
+	if p.format == Table {
+		fmt.Fprintf(p.w, "\nTotal (BTC): %v, Mean Distance: %.3f\n", plan.Total().BTC(), plan.MeanDistance)
+	}
+
+	return nil
+}
+
+// batchChannel matches lnd's batch open channel format.
+type batchChannel struct {
+	NodePubkey         lightning.PubKey  `json:"node_pubkey"`
+	LocalFundingAmount lightning.Satoshi `json:"local_funding_amount"`
+}
+
+// BatchPlan in the JSON format accepted by lncli batchopenchannel, which is its own format so only goes with tables.
+func (p Printer) BatchPlan(plan raiju.Plan) error {
+	if p.format != Table {
+		return fmt.Errorf("unable to print a batch plan as %s", p.format)
+	}
+
+	channels := make([]batchChannel, len(plan.Channels))
+	for i, c := range plan.Channels {
+		channels[i] = batchChannel{
+			NodePubkey:         c.PubKey,
+			LocalFundingAmount: c.Amount,
+		}
+	}
+
+	return json.NewEncoder(p.w).Encode(channels)
+}
+
+type distanceRecord struct {
+	Distance    int64 `json:"distance"`
+	Nodes       int64 `json:"nodes"`
+	WithChannel int64 `json:"with_channel"`
+}
+
+type explanationRecord struct {
+	PubKey              lightning.PubKey   `json:"pubkey"`
+	Alias               string             `json:"alias"`
+	Candidate           bool               `json:"candidate"`
+	Exclusions          []string           `json:"exclusions"`
+	Path                []lightning.PubKey `json:"path"`
+	DistantNeighbors    []lightning.PubKey `json:"distant_neighbors"`
+	Distances           []distanceRecord   `json:"distances"`
+	MeanDistance        float64            `json:"mean_distance"`
+	AssumedMeanDistance float64            `json:"with_channel_mean_distance"`
+}
+
+// Explanation of a node's standing as a candidate, a single nested record so it has no CSV form.
+func (p Printer) Explanation(e raiju.Explanation) error {
+	var furthest int64
+	for d := range e.Distances {
+		if d > furthest {
+			furthest = d
+		}
+	}
+
+	switch p.format {
+	case Table:
+		return tableExplanation(p.w, e, furthest)
+	case CSV:
+		return errors.New("unable to print an explanation as csv")
+	}
+
+	record := explanationRecord{
+		PubKey:              e.PubKey,
+		Alias:               e.Alias,
+		Candidate:           len(e.Exclusions) == 0,
+		Exclusions:          append([]string{}, e.Exclusions...),
+		Path:                []lightning.PubKey{},
+		DistantNeighbors:    []lightning.PubKey{},
+		Distances:           []distanceRecord{},
+		MeanDistance:        e.MeanDistance,
+		AssumedMeanDistance: e.AssumedMeanDistance,
+	}
+	for _, n := range e.Path {
+		record.Path = append(record.Path, n.PubKey)
+	}
+	for _, n := range e.DistantNeighbors {
+		record.DistantNeighbors = append(record.DistantNeighbors, n.PubKey)
+	}
+	for d := int64(1); d <= furthest; d++ {
+		record.Distances = append(record.Distances, distanceRecord{Distance: d, Nodes: e.Distances[d], WithChannel: e.AssumedDistances[d]})
+	}
+
+	return json.NewEncoder(p.w).Encode(record)
+}
+
+// tableExplanation for humans, distances are listed out to the furthest.
+func tableExplanation(w io.Writer, e raiju.Explanation, furthest int64) error {
+	fmt.Fprintf(w, "%s (%s)\n\n", e.PubKey, e.Alias)
+
+	if len(e.Exclusions) == 0 {
+		fmt.Fprintln(w, "Candidate: yes")
+	} else {
+		fmt.Fprintln(w, "Candidate: no")
+		for _, x := range e.Exclusions {
+			fmt.Fprintf(w, "  - %s\n", x)
+		}
+	}
+
+	hops := make([]string, len(e.Path))
+	for i, n := range e.Path {
+		hops[i] = n.Alias
+		if hops[i] == "" {
+			hops[i] = string(n.PubKey)
+		}
+	}
+	if len(hops) == 0 {
+		fmt.Fprintln(w, "\nPath: unreachable from the root nodes")
+	} else {
+		fmt.Fprintf(w, "\nPath: %s\n", strings.Join(hops, " -> "))
+	}
+
+	fmt.Fprintf(w, "\nDistant Neighbors (%d):\n", len(e.DistantNeighbors))
+	for _, n := range e.DistantNeighbors {
+		fmt.Fprintf(w, "  %s %s\n", n.PubKey, n.Alias)
+	}
+
+	fmt.Fprintln(w)
+	tbl := table.New("Distance", "Nodes", "With Channel", "Change").WithWriter(w)
+	for d := int64(1); d <= furthest; d++ {
+		tbl.AddRow(d, e.Distances[d], e.AssumedDistances[d], e.AssumedDistances[d]-e.Distances[d])
+	}
+	tbl.Print()
+
+	fmt.Fprintf(w, "\nMean Distance: %.3f, With Channel: %.3f\n", e.MeanDistance, e.AssumedMeanDistance)
+
+	return nil
+}
+
+type channelRecord struct {
+	ChannelID     lightning.ChannelID `json:"channel_id"`
+	PubKey        lightning.PubKey    `json:"pubkey"`
+	Alias         string              `json:"alias"`
+	Capacity      lightning.Satoshi   `json:"capacity_sat"`
+	LocalBalance  lightning.Satoshi   `json:"local_balance_sat"`
+	RemoteBalance lightning.Satoshi   `json:"remote_balance_sat"`
+	LocalFee      lightning.FeePPM    `json:"local_fee_ppm"`
+}
+
+// Channels of the node.
+func (p Printer) Channels(channels lightning.Channels) error {
+	header := []interface{}{"Channel ID", "Alias", "Capacity (BTC)"}
+
+	rows := make([][]interface{}, len(channels))
+	records := make([]channelRecord, len(channels))
+	for i, c := range channels {
+		rows[i] = []interface{}{c.ChannelID, c.RemoteNode.Alias, c.Capacity.BTC()}
+		records[i] = channelRecord{
+			ChannelID:     c.ChannelID,
+			PubKey:        c.RemoteNode.PubKey,
+			Alias:         c.RemoteNode.Alias,
+			Capacity:      c.Capacity,
+			LocalBalance:  c.LocalBalance,
+			RemoteBalance: c.RemoteBalance,
+			LocalFee:      c.LocalFee,
+		}
+	}
+
+	return output(p, header, rows, records)
+}
+
+type liquidityFeeRecord struct {
+	Threshold float64          `json:"local_liquidity_threshold_percent"`
+	Fee       lightning.FeePPM `json:"fee_ppm"`
+}
+
+// Fees settings by local liquidity.
+func (p Printer) Fees(lf raiju.LiquidityFees) error {
+	header := []interface{}{"Local Liquidity Threshold Percent", "Fee PPM"}
+
+	var rows [][]interface{}
+	var records []liquidityFeeRecord
+	for i := 0; i < len(lf.Thresholds); i++ {
+		rows = append(rows, []interface{}{lf.Thresholds[i], lf.Fees[i]})
+		records = append(records, liquidityFeeRecord{Threshold: lf.Thresholds[i], Fee: lf.Fees[i]})
+	}
+	rows = append(rows, []interface{}{0, lf.Fees[len(lf.Fees)-1]})
+	records = append(records, liquidityFeeRecord{Threshold: 0, Fee: lf.Fees[len(lf.Fees)-1]})
+
+	return output(p, header, rows, records)
+}
+
+type feeUpdateRecord struct {
+	ChannelID lightning.ChannelID `json:"channel_id"`
+	Fee       lightning.FeePPM    `json:"fee_ppm"`
+}
+
+// FeeUpdates applied to channels.
+func (p Printer) FeeUpdates(updates map[lightning.ChannelID]lightning.FeePPM) error {
+	header := []interface{}{"Channel ID", "Fee PPM"}
+
+	var rows [][]interface{}
+	var records []feeUpdateRecord
+	for _, id := range sortedIDs(updates) {
+		rows = append(rows, []interface{}{id, updates[id]})
+		records = append(records, feeUpdateRecord{ChannelID: id, Fee: updates[id]})
+	}
+
+	return output(p, header, rows, records)
+}
+
+type rebalanceResultRecord struct {
+	Out          lightning.ChannelID `json:"out_channel_id"`
+	In           lightning.ChannelID `json:"in_channel_id"`
+	Moved        lightning.Satoshi   `json:"moved_sat"`
+	Fees         lightning.Satoshi   `json:"fees_sat"`
+	EffectiveFee lightning.FeePPM    `json:"effective_fee_ppm"`
+	Attempts     int                 `json:"attempts"`
+	Duration     float64             `json:"duration_seconds"`
+}
+
+// RebalanceResults of each pair attempted, with a total row for humans.
+func (p Printer) RebalanceResults(results raiju.RebalanceResults) error {
+	header := []interface{}{"Out Channel", "In Channel", "Moved (sats)", "Fees (sats)", "Effective Fee PPM", "Attempts", "Duration"}
+
+	var rows [][]interface{}
+	records := make([]rebalanceResultRecord, len(results))
+	for i, r := range results {
+		rows = append(rows, []interface{}{r.Out, r.In, r.Moved, r.Fees, fmt.Sprintf("%.0f", r.EffectivePPM()), len(r.Attempts), r.Duration.Round(time.Second)})
+		records[i] = rebalanceResultRecord{
+			Out:          r.Out,
+			In:           r.In,
+			Moved:        r.Moved,
+			Fees:         r.Fees,
+			EffectiveFee: r.EffectivePPM(),
+			Attempts:     len(r.Attempts),
+			Duration:     r.Duration.Seconds(),
+		}
+	}
+	if len(results) > 1 {
+		t := results.Total()
+		rows = append(rows, []interface{}{"Total", "", t.Moved, t.Fees, fmt.Sprintf("%.0f", t.EffectivePPM()), len(t.Attempts), t.Duration.Round(time.Second)})
+	}
+
+	return output(p, header, rows, records)
+}
+
+type attemptRecord struct {
+	Time          time.Time           `json:"time"`
+	Out           lightning.ChannelID `json:"out_channel_id"`
+	In            lightning.ChannelID `json:"in_channel_id"`
+	Amount        lightning.Satoshi   `json:"amount_sat"`
+	Fee           lightning.Satoshi   `json:"fee_sat"`
+	Hops          int                 `json:"hops"`
+	Succeeded     bool                `json:"succeeded"`
+	FailureCode   string              `json:"failure_code"`
+	FailureSource lightning.PubKey    `json:"failure_source"`
+	Error         string              `json:"error"`
+}
+
+// Attempts of rebalance payments.
+func (p Printer) Attempts(attempts []raiju.RebalanceAttempt) error {
+	header := []interface{}{"Out Channel", "In Channel", "Amount (sats)", "Fee (sats)", "Hops", "Succeeded", "Failure"}
+
+	rows := make([][]interface{}, len(attempts))
+	records := make([]attemptRecord, len(attempts))
+	for i, a := range attempts {
+		failure := a.FailureCode
+		if a.FailureSource != "" {
+			failure = fmt.Sprintf("%s at %s", failure, a.FailureSource)
+		}
+		if failure == "" {
+			failure = a.Error
+		}
+		rows[i] = []interface{}{a.Out, a.In, a.Amount, a.Fee, len(a.Route), a.Succeeded, failure}
+		records[i] = attemptRecord{
+			Time:          a.Time,
+			Out:           a.Out,
+			In:            a.In,
+			Amount:        a.Amount,
+			Fee:           a.Fee,
+			Hops:          len(a.Route),
+			Succeeded:     a.Succeeded,
+			FailureCode:   a.FailureCode,
+			FailureSource: a.FailureSource,
+			Error:         a.Error,
+		}
+	}
+
+	return output(p, header, rows, records)
+}
+
+type pairRecord struct {
+	Out         lightning.ChannelID `json:"out_channel_id"`
+	In          lightning.ChannelID `json:"in_channel_id"`
+	Attempts    int64               `json:"attempts"`
+	Successes   int64               `json:"successes"`
+	SuccessRate float64             `json:"success_rate_percent"`
+	Moved       lightning.Satoshi   `json:"moved_sat"`
+	Fees        lightning.Satoshi   `json:"fees_sat"`
+}
+
+// RebalancePairs success of past rebalances.
+func (p Printer) RebalancePairs(pairs []raiju.PairStats) error {
+	header := []interface{}{"Out Channel", "In Channel", "Attempts", "Successes", "Success Rate", "Moved (sats)", "Fees (sats)"}
+
+	rows := make([][]interface{}, len(pairs))
+	records := make([]pairRecord, len(pairs))
+	for i, s := range pairs {
+		rows[i] = []interface{}{s.Out, s.In, s.Attempts, s.Successes, fmt.Sprintf("%.1f%%", s.SuccessRate()), s.Moved, s.Fees}
+		records[i] = pairRecord{
+			Out:         s.Out,
+			In:          s.In,
+			Attempts:    s.Attempts,
+			Successes:   s.Successes,
+			SuccessRate: s.SuccessRate(),
+			Moved:       s.Moved,
+			Fees:        s.Fees,
+		}
+	}
+
+	return output(p, header, rows, records)
+}
+
+type failureRecord struct {
+	Node     lightning.PubKey `json:"pubkey"`
+	Code     string           `json:"failure_code"`
+	Failures int64            `json:"failures"`
+}
+
+// FailurePoints where past rebalances failed.
+func (p Printer) FailurePoints(points []raiju.FailurePoint) error {
+	header := []interface{}{"Pubkey", "Failure Code", "Failures"}
+
+	rows := make([][]interface{}, len(points))
+	records := make([]failureRecord, len(points))
+	for i, f := range points {
+		node := string(f.Node)
+		if node == "" {
+			node = "local"
+		}
+		code := f.Code
+		if code == "" {
+			code = "-"
+		}
+		rows[i] = []interface{}{node, code, f.Failures}
+		records[i] = failureRecord{Node: f.Node, Code: f.Code, Failures: f.Failures}
+	}
+
+	return output(p, header, rows, records)
+}
+
+type invoiceRecord struct {
+	Settled time.Time         `json:"settled"`
+	Amount  lightning.Satoshi `json:"amount_sat"`
+	Memo    string            `json:"memo"`
+}
+
+// Invoices settled by the node.
+func (p Printer) Invoices(invoices []lightning.SettledInvoice) error {
+	header := []interface{}{"Settled", "Amount (sats)", "Memo"}
+
+	rows := make([][]interface{}, len(invoices))
+	records := make([]invoiceRecord, len(invoices))
+	for i, v := range invoices {
+		rows[i] = []interface{}{v.Settled.Format(time.DateTime), v.Amount, v.Memo}
+		records[i] = invoiceRecord{Settled: v.Settled, Amount: v.Amount, Memo: v.Memo}
+	}
+
+	return output(p, header, rows, records)
+}
+
+// sortedIDs of a map keyed by channel for stable output.
+func sortedIDs[V any](m map[lightning.ChannelID]V) []lightning.ChannelID {
+	ids := make([]lightning.ChannelID, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// output rows as a table or records in a machine readable format.
+func output[T any](p Printer, header []interface{}, rows [][]interface{}, records []T) error {
+	switch p.format {
+	case JSON:
+		if records == nil {
+			records = []T{}
+		}
+		return json.NewEncoder(p.w).Encode(records)
+	case JSONLines:
+		e := json.NewEncoder(p.w)
+		for _, r := range records {
+			if err := e.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		return writeCSV(p.w, records)
+	default:
+		tbl := table.New(header...).WithWriter(p.w)
+		for _, r := range rows {
+			tbl.AddRow(r...)
+		}
+		tbl.Print()
+		return nil
+	}
+}
+
+// writeCSV of records with their JSON keys as the header.
+func writeCSV[T any](w io.Writer, records []T) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	cw := csv.NewWriter(w)
+
+	header := make([]string, t.NumField())
+	for i := range header {
+		header[i] = strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		v := reflect.ValueOf(r)
+		row := make([]string, t.NumField())
+		for i := range row {
+			row[i] = cell(v.Field(i).Interface())
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell formats a value for a CSV field.
+func cell(v interface{}) string {
+	switch c := v.(type) {
+	case time.Time:
+		return c.Format(time.RFC3339)
+	case []string:
+		return strings.Join(c, " ")
+	default:
+		return fmt.Sprint(c)
+	}
+}
@@ -0,0 +1,193 @@
+package view
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nyonson/raiju"
+	"github.com/nyonson/raiju/lightning"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Format
+		wantErr bool
+	}{
+		{
+			name: "json lines",
+			s:    "jsonl",
+			want: JSONLines,
+		},
+		{
+			name:    "unknown",
+			s:       "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_FeeUpdates(t *testing.T) {
+	updates := map[lightning.ChannelID]lightning.FeePPM{
+		2: 50,
+		1: 5,
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "json",
+			format: JSON,
+			want:   "[{\"channel_id\":1,\"fee_ppm\":5},{\"channel_id\":2,\"fee_ppm\":50}]\n",
+		},
+		{
+			name:   "json lines",
+			format: JSONLines,
+			want:   "{\"channel_id\":1,\"fee_ppm\":5}\n{\"channel_id\":2,\"fee_ppm\":50}\n",
+		},
+		{
+			name:   "csv",
+			format: CSV,
+			want:   "channel_id,fee_ppm\n1,5\n2,50\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := NewPrinter(&b, tt.format).FeeUpdates(updates); err != nil {
+				t.Errorf("Printer.FeeUpdates() error = %v", err)
+				return
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Printer.FeeUpdates() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_RebalanceResults(t *testing.T) {
+	results := raiju.RebalanceResults{
+		{Out: 1, In: 2, Moved: 1000, Fees: 2, Attempts: []raiju.RebalanceAttempt{{}, {}}, Duration: 3 * time.Second},
+		{Out: 3, In: 2, Attempts: []raiju.RebalanceAttempt{{}}, Duration: time.Second},
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "csv",
+			format: CSV,
+			want:   "out_channel_id,in_channel_id,moved_sat,fees_sat,effective_fee_ppm,attempts,duration_seconds\n1,2,1000,2,2000,2,3\n3,2,0,0,0,1,1\n",
+		},
+		{
+			name:   "json lines",
+			format: JSONLines,
+			want:   "{\"out_channel_id\":1,\"in_channel_id\":2,\"moved_sat\":1000,\"fees_sat\":2,\"effective_fee_ppm\":2000,\"attempts\":2,\"duration_seconds\":3}\n{\"out_channel_id\":3,\"in_channel_id\":2,\"moved_sat\":0,\"fees_sat\":0,\"effective_fee_ppm\":0,\"attempts\":1,\"duration_seconds\":1}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := NewPrinter(&b, tt.format).RebalanceResults(results); err != nil {
+				t.Errorf("Printer.RebalanceResults() error = %v", err)
+				return
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Printer.RebalanceResults() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_Plan(t *testing.T) {
+	plan := raiju.Plan{
+		Channels: []raiju.PlannedChannel{
+			{RelativeNode: raiju.RelativeNode{Node: lightning.Node{PubKey: "A", Alias: "a"}, Root: "R", Distance: 3}, Amount: 1000},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  Format
+		batch   bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "csv",
+			format: CSV,
+			want:   "pubkey,alias,root,distance,distant_neighbors,capacity_sat,channels,amount_sat\nA,a,R,3,0,0,0,1000\n",
+		},
+		{
+			name:   "batch",
+			format: Table,
+			batch:  true,
+			want:   "[{\"node_pubkey\":\"A\",\"local_funding_amount\":1000}]\n",
+		},
+		{
+			name:    "batch is only its own format",
+			format:  JSON,
+			batch:   true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			p := NewPrinter(&b, tt.format)
+			print := p.Plan
+			if tt.batch {
+				print = p.BatchPlan
+			}
+			if err := print(plan); (err != nil) != tt.wantErr {
+				t.Fatalf("Printer.Plan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Printer.Plan() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter_Explanation(t *testing.T) {
+	e := raiju.Explanation{
+		RelativeNode:     raiju.RelativeNode{Node: lightning.Node{PubKey: "A", Alias: "a"}},
+		Path:             []lightning.Node{{PubKey: "R"}, {PubKey: "A"}},
+		Exclusions:       []string{"too close"},
+		Distances:        map[int64]int64{1: 2, 2: 4},
+		AssumedDistances: map[int64]int64{1: 3, 2: 3},
+		MeanDistance:     1.5,
+	}
+
+	var b bytes.Buffer
+	if err := NewPrinter(&b, JSONLines).Explanation(e); err != nil {
+		t.Fatalf("Printer.Explanation() error = %v", err)
+	}
+	want := "{\"pubkey\":\"A\",\"alias\":\"a\",\"candidate\":false,\"exclusions\":[\"too close\"],\"path\":[\"R\",\"A\"],\"distant_neighbors\":[],\"distances\":[{\"distance\":1,\"nodes\":2,\"with_channel\":3},{\"distance\":2,\"nodes\":4,\"with_channel\":3}],\"mean_distance\":1.5,\"with_channel_mean_distance\":0}\n"
+	if got := b.String(); got != want {
+		t.Errorf("Printer.Explanation() = %q, want %q", got, want)
+	}
+
+	if err := NewPrinter(&b, CSV).Explanation(e); err == nil {
+		t.Error("Printer.Explanation() expected error for csv")
+	}
+}
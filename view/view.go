@@ -1,20 +1,35 @@
 package view
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gdamore/tcell/v2"
+
 	"github.com/nyonson/raiju"
 	"github.com/nyonson/raiju/lightning"
 	"github.com/rivo/tview"
 )
 
-func ViewCandidates(ctx context.Context, r raiju.Raiju) (*tview.Flex, error) {
+// ViewCandidates ranked by the min filters and the channels assumed to be opened.
+//
+// Press m to mark the selected candidate as assumed, c to clear the assumed nodes, and e to export them as a node
+// list to exportPath. Refresh re-runs the search with channels assumed to the marked nodes.
+func ViewCandidates(ctx context.Context, app *tview.Application, r raiju.Raiju, log *tview.TextView, exportPath string) (*tview.Flex, error) {
 	container := tview.NewFlex()
 	container.SetBorder(true).SetTitle("Candidates")
 
-	table := tview.NewTable().SetSelectable(true, false)
+	table := tview.NewTable().SetSelectable(true, false).SetFixed(1, 0)
+	detail := tview.NewTextView()
+	detail.SetBorder(true).SetTitle("Detail")
 
 	form := tview.NewForm().
 		AddInputField("Capacity", "10000000", 12, nil, nil).
@@ -23,12 +38,107 @@ func ViewCandidates(ctx context.Context, r raiju.Raiju) (*tview.Flex, error) {
 
 	form.SetBorder(true).SetTitle("Min Filters")
 
-	form.AddButton("Refresh", func() {
+	// only touched on the draw goroutine
+	var nodes []raiju.RelativeNode
+	var assumed []lightning.Node
+	searching := false
+
+	isAssumed := func(pubKey lightning.PubKey) bool {
+		for _, n := range assumed {
+			if n.PubKey == pubKey {
+				return true
+			}
+		}
+		return false
+	}
+
+	selected := func() (raiju.RelativeNode, bool) {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(nodes) {
+			return raiju.RelativeNode{}, false
+		}
+		return nodes[row-1], true
+	}
+
+	showDetail := func() {
+		n, ok := selected()
+		if !ok {
+			detail.Clear()
+			return
+		}
+
+		neighbors := make([]string, len(n.Neighbors))
+		for i, p := range n.Neighbors {
+			neighbors[i] = string(p)
+		}
+
+		detail.SetText(fmt.Sprintf("%s\n%s\n\nCapacity: %.8f BTC\nChannels: %d\nDistance: %d\nDistant Neighbors: %d\nUpdated: %s ago\nAddresses:\n  %s\nNeighbors (%d):\n  %s",
+			n.Alias,
+			n.PubKey,
+			n.Capacity.BTC(),
+			n.Channels,
+			n.Distance,
+			n.DistantNeigbors,
+			age(n.Updated),
+			strings.Join(n.Addresses, "\n  "),
+			len(n.Neighbors),
+			strings.Join(neighbors, "\n  "),
+		))
+	}
+
+	draw := func() {
 		table.Clear()
+		table.SetCell(0, 0, tview.NewTableCell("Assume").SetSelectable(false))
+		table.SetCell(0, 1, tview.NewTableCell("Alias").SetSelectable(false))
+		table.SetCell(0, 2, tview.NewTableCell("PubKey").SetSelectable(false))
+		table.SetCell(0, 3, tview.NewTableCell("Distance").SetSelectable(false))
+		table.SetCell(0, 4, tview.NewTableCell("Distant Neighbors").SetSelectable(false))
+
+		for i, n := range nodes {
+			row := i + 1
+
+			mark := ""
+			if isAssumed(n.PubKey) {
+				mark = "*"
+			}
+
+			table.SetCellSimple(row, 0, mark)
+			table.SetCellSimple(row, 1, n.Alias)
+			table.SetCellSimple(row, 2, string(n.PubKey))
+			table.SetCellSimple(row, 3, strconv.FormatInt(n.Distance, 10))
+			table.SetCellSimple(row, 4, strconv.FormatInt(n.DistantNeigbors, 10))
+		}
 
-		minCapacity, _ := strconv.Atoi(form.GetFormItem(0).(*tview.InputField).GetText())
-		minDistance, _ := strconv.Atoi(form.GetFormItem(1).(*tview.InputField).GetText())
-		minDistantNeighbors, _ := strconv.Atoi(form.GetFormItem(2).(*tview.InputField).GetText())
+		container.SetTitle(fmt.Sprintf("Candidates (%d, %d assumed)", len(nodes), len(assumed)))
+		showDetail()
+	}
+
+	refresh := func() {
+		if searching {
+			printLog(log, "waiting on the last candidates search to finish")
+			return
+		}
+
+		minCapacity, err := strconv.Atoi(form.GetFormItem(0).(*tview.InputField).GetText())
+		if err != nil {
+			printLog(log, "capacity must be a number of satoshis")
+			return
+		}
+		minDistance, err := strconv.Atoi(form.GetFormItem(1).(*tview.InputField).GetText())
+		if err != nil {
+			printLog(log, "distance must be a number")
+			return
+		}
+		minDistantNeighbors, err := strconv.Atoi(form.GetFormItem(2).(*tview.InputField).GetText())
+		if err != nil {
+			printLog(log, "distant neighbors must be a number")
+			return
+		}
+
+		assume := make([]lightning.PubKey, len(assumed))
+		for i, n := range assumed {
+			assume[i] = n.PubKey
+		}
 
 		request := raiju.CandidatesRequest{
 			MinCapacity:         lightning.Satoshi(minCapacity),
@@ -36,44 +146,449 @@ func ViewCandidates(ctx context.Context, r raiju.Raiju) (*tview.Flex, error) {
 			MinDistance:         int64(minDistance),
 			MinDistantNeighbors: int64(minDistantNeighbors),
 			MinUpdated:          time.Now().Add(-2 * 24 * time.Hour),
+			Assume:              assume,
 			Limit:               200,
 			Clearnet:            true,
 		}
-		nodes, err := r.Candidates(ctx, request)
-		if err != nil {
+
+		searching = true
+		go func() {
+			logf(app, log, "searching for candidates assuming %d channels...", len(assume))
+			found, err := r.Candidates(ctx, request)
+			if err != nil {
+				app.QueueUpdate(func() {
+					searching = false
+				})
+				logf(app, log, "unable to find candidates: %s", err)
+				return
+			}
+
+			app.QueueUpdateDraw(func() {
+				searching = false
+				nodes = found
+				draw()
+				table.ScrollToBeginning()
+			})
+			logf(app, log, "found %d candidates", len(found))
+		}()
+	}
+
+	export := func() {
+		if len(assumed) == 0 {
+			printLog(log, "mark candidates to assume with m before exporting")
 			return
 		}
 
-		// headers
-		// would like to show aliases, but double width emoji are the worst
-		// tview might handle it better if this gets in: https://github.com/mattn/go-runewidth/pull/63
-		table.SetCellSimple(0, 0, "PubKey")
-		table.SetCellSimple(0, 1, "Distance")
-		table.SetCellSimple(0, 2, "Distant Neighbors")
-		// always show header row
-		table.SetFixed(1, 0)
+		var b bytes.Buffer
+		if err := raiju.WriteNodeList(&b, assumed); err != nil {
+			printLog(log, "unable to export assumed nodes: %s", err)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(exportPath), 0700); err != nil {
+			printLog(log, "unable to export assumed nodes: %s", err)
+			return
+		}
+		if err := os.WriteFile(exportPath, b.Bytes(), 0600); err != nil {
+			printLog(log, "unable to export assumed nodes: %s", err)
+			return
+		}
+
+		pubKeys := make([]string, len(assumed))
+		for i, n := range assumed {
+			pubKeys[i] = string(n.PubKey)
+		}
+		printLog(log, "exported %d assumed nodes to %s, -assume %s", len(assumed), exportPath, strings.Join(pubKeys, ","))
+	}
+
+	form.AddButton("Refresh", refresh)
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		showDetail()
+	})
 
-		// content
-		row := 1
-		for _, n := range nodes {
-			table.SetCellSimple(row, 0, string(n.PubKey))
-			table.SetCellSimple(row, 1, strconv.FormatInt(n.Distance, 10))
-			table.SetCellSimple(row, 2, strconv.FormatInt(n.DistantNeigbors, 10))
-			row++
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'm':
+			n, ok := selected()
+			if !ok {
+				return nil
+			}
+			if isAssumed(n.PubKey) {
+				for i, a := range assumed {
+					if a.PubKey == n.PubKey {
+						assumed = append(assumed[:i], assumed[i+1:]...)
+						break
+					}
+				}
+			} else {
+				assumed = append(assumed, n.Node)
+			}
+			draw()
+		case 'c':
+			assumed = nil
+			draw()
+		case 'e':
+			export()
+		default:
+			return event
 		}
 
-		table.ScrollToBeginning()
+		return nil
 	})
 
-	container.AddItem(form, 36, 1, true)
-	container.AddItem(table, 0, 4, false)
+	right := tview.NewFlex().SetDirection(tview.FlexRow)
+	right.AddItem(table, 0, 2, false)
+	right.AddItem(detail, 0, 1, false)
+
+	left := tview.NewFlex().SetDirection(tview.FlexRow)
+	left.AddItem(form, 0, 1, true)
+	left.AddItem(tview.NewTextView().SetText("m mark assumed\nc clear assumed\ne export assumed"), 4, 0, false)
+
+	container.AddItem(left, 36, 1, true)
+	container.AddItem(right, 0, 4, false)
 
 	return container, nil
 }
 
-func ViewChannels(ctx context.Context, r raiju.Raiju) (*tview.Flex, error) {
+// channelSorts are the orders the channels table cycles through.
+var channelSorts = []struct {
+	name string
+	less func(a, b raiju.ChannelStatus) bool
+}{
+	{"liquidity", func(a, b raiju.ChannelStatus) bool { return a.Liquidity() < b.Liquidity() }},
+	{"capacity", func(a, b raiju.ChannelStatus) bool { return a.Capacity > b.Capacity }},
+	{"forwards", func(a, b raiju.ChannelStatus) bool { return a.Forwards > b.Forwards }},
+	{"alias", func(a, b raiju.ChannelStatus) bool {
+		return strings.ToLower(a.RemoteNode.Alias) < strings.ToLower(b.RemoteNode.Alias)
+	}},
+}
+
+// liquidityBar of a channel's local liquidity percent.
+func liquidityBar(percent float64, width int) string {
+	filled := int(math.Round(percent / 100 * float64(width)))
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+
+	return fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", filled), strings.Repeat("-", width-filled), percent)
+}
+
+// age since t in a short human format.
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// ViewLog of the actions taken from the dashboard.
+func ViewLog() *tview.TextView {
+	log := tview.NewTextView().SetScrollable(true)
+	log.SetBorder(true).SetTitle("Log")
+
+	return log
+}
+
+// logf a line to the log from a background goroutine, it blocks until the event loop writes it so must never be
+// called from the event loop itself.
+func logf(app *tview.Application, log *tview.TextView, format string, a ...interface{}) {
+	line := fmt.Sprintf(format, a...)
+	app.QueueUpdateDraw(func() {
+		printLog(log, "%s", line)
+	})
+}
+
+// printLog a line to the log from the event loop, like in key and button handlers.
+func printLog(log *tview.TextView, format string, a ...interface{}) {
+	fmt.Fprintf(log, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, a...))
+	log.ScrollToEnd()
+}
+
+// ViewChannels with their liquidity, kept up to date as forwards shift it.
+//
+// Press s to cycle the sort order, o and i to mark the out and in channels of a rebalance, r to rebalance them,
+// p to preview fee changes, and a to apply them. Results are written to the log and rebalance attempts are passed
+// to record.
+func ViewChannels(ctx context.Context, app *tview.Application, r raiju.Raiju, log *tview.TextView, record func([]raiju.RebalanceAttempt) error) (*tview.Flex, error) {
 	container := tview.NewFlex()
 	container.SetBorder(true).SetTitle("Channels")
 
+	table := tview.NewTable().SetSelectable(true, false).SetFixed(1, 0)
+	detail := tview.NewTextView()
+	detail.SetBorder(true).SetTitle("Detail")
+
+	form := tview.NewForm().
+		AddInputField("Percent", "5", 6, nil, nil).
+		AddInputField("Max Fee PPM", "0", 8, nil, nil).
+		AddInputField("Max Parts", "1", 4, nil, nil)
+	form.SetBorder(true).SetTitle("Rebalance")
+
+	help := tview.NewTextView().SetText("s sort\no mark out\ni mark in\nr rebalance\np preview fees\na apply fees")
+
+	actions := tview.NewFlex().SetDirection(tview.FlexRow)
+	actions.AddItem(form, 9, 0, false)
+	actions.AddItem(help, 0, 1, false)
+
+	container.AddItem(table, 0, 3, true)
+	container.AddItem(detail, 0, 1, false)
+	container.AddItem(actions, 26, 0, false)
+
+	updates, errors, err := r.WatchChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// only touched on the draw goroutine
+	var statuses []raiju.ChannelStatus
+	var out, in lightning.ChannelID
+	sortBy := 0
+	running := false
+
+	alias := func(id lightning.ChannelID) string {
+		for _, c := range statuses {
+			if c.ChannelID == id {
+				return c.RemoteNode.Alias
+			}
+		}
+		return strconv.FormatUint(uint64(id), 10)
+	}
+
+	selected := func() (raiju.ChannelStatus, bool) {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(statuses) {
+			return raiju.ChannelStatus{}, false
+		}
+		return statuses[row-1], true
+	}
+
+	showDetail := func() {
+		c, ok := selected()
+		if !ok {
+			detail.Clear()
+			return
+		}
+
+		detail.SetText(fmt.Sprintf("%s\n%s\n\nChannel ID: %d\nCapacity: %.8f BTC\nLocal: %.8f BTC\nRemote: %.8f BTC\nLiquidity: %.1f%%\nFee PPM: %.0f\nTarget Fee PPM: %.0f\nFee Updated: %s\nForwards (7d): %d\nPrivate: %t\nAddresses: %s",
+			c.RemoteNode.Alias,
+			c.RemoteNode.PubKey,
+			c.ChannelID,
+			c.Capacity.BTC(),
+			c.LocalBalance.BTC(),
+			c.RemoteBalance.BTC(),
+			c.Liquidity(),
+			float64(c.LocalFee),
+			float64(c.TargetFee),
+			age(c.LocalFeeUpdated),
+			c.Forwards,
+			c.Private,
+			strings.Join(c.RemoteNode.Addresses, ", "),
+		))
+	}
+
+	draw := func() {
+		// keep the selection on the same channel across redraws
+		var current lightning.ChannelID
+		if c, ok := selected(); ok {
+			current = c.ChannelID
+		}
+
+		sort.SliceStable(statuses, func(i, j int) bool {
+			return channelSorts[sortBy].less(statuses[i], statuses[j])
+		})
+
+		table.Clear()
+		for i, h := range []string{"Mark", "Alias", "Capacity (BTC)", "Liquidity", "Fee PPM", "Fee Updated", "Forwards (7d)"} {
+			table.SetCell(0, i, tview.NewTableCell(h).SetSelectable(false))
+		}
+
+		for i, c := range statuses {
+			row := i + 1
+
+			mark := ""
+			switch c.ChannelID {
+			case out:
+				mark = "out"
+			case in:
+				mark = "in"
+			}
+
+			fee := strconv.FormatFloat(float64(c.LocalFee), 'f', 0, 64)
+			if c.TargetFee != c.LocalFee {
+				fee = fmt.Sprintf("%s -> %.0f", fee, float64(c.TargetFee))
+			}
+
+			table.SetCellSimple(row, 0, mark)
+			table.SetCellSimple(row, 1, c.RemoteNode.Alias)
+			table.SetCellSimple(row, 2, strconv.FormatFloat(c.Capacity.BTC(), 'f', 8, 64))
+			table.SetCellSimple(row, 3, liquidityBar(c.Liquidity(), 20))
+			table.SetCellSimple(row, 4, fee)
+			table.SetCellSimple(row, 5, age(c.LocalFeeUpdated))
+			table.SetCellSimple(row, 6, strconv.FormatInt(c.Forwards, 10))
+
+			if c.ChannelID == current {
+				table.Select(row, 0)
+			}
+		}
+
+		container.SetTitle(fmt.Sprintf("Channels (%d, sorted by %s)", len(statuses), channelSorts[sortBy].name))
+		showDetail()
+	}
+
+	// run an action in the background, one at a time so they don't fight over liquidity
+	run := func(action func()) {
+		if running {
+			printLog(log, "waiting on the last action to finish")
+			return
+		}
+		running = true
+
+		go func() {
+			action()
+			app.QueueUpdate(func() {
+				running = false
+			})
+		}()
+	}
+
+	rebalance := func() {
+		if out == 0 || in == 0 {
+			printLog(log, "mark an out channel with o and an in channel with i to rebalance")
+			return
+		}
+
+		percent, err := strconv.ParseFloat(form.GetFormItem(0).(*tview.InputField).GetText(), 64)
+		if err != nil || percent <= 0 {
+			printLog(log, "percent must be a positive number")
+			return
+		}
+		maxFee, err := strconv.ParseFloat(form.GetFormItem(1).(*tview.InputField).GetText(), 64)
+		if err != nil || maxFee < 0 {
+			printLog(log, "max fee PPM must be zero (the default) or a positive number")
+			return
+		}
+		maxParts, err := strconv.ParseUint(form.GetFormItem(2).(*tview.InputField).GetText(), 10, 32)
+		if err != nil {
+			printLog(log, "max parts must be a positive number")
+			return
+		}
+
+		o, i := out, in
+		outAlias, inAlias := alias(o), alias(i)
+		run(func() {
+			logf(app, log, "rebalancing up to %.1f%% of %s into %s...", percent, outAlias, inAlias)
+			result, err := r.RebalancePair(ctx, o, i, raiju.RebalanceRequest{
+				MaxPercent: percent,
+				MaxFee:     lightning.FeePPM(maxFee),
+				MaxParts:   uint32(maxParts),
+			})
+			if rerr := record(result.Attempts); rerr != nil {
+				logf(app, log, "%s", rerr)
+			}
+			if err != nil {
+				logf(app, log, "unable to rebalance %s into %s: %s", outAlias, inAlias, err)
+				return
+			}
+			logf(app, log, "moved %d sats out of %s into %s for %d sats (%.0f ppm) in %d attempts", result.Moved, outAlias, inAlias, result.Fees, result.EffectivePPM(), len(result.Attempts))
+		})
+	}
+
+	fees := func(apply bool) {
+		aliases := make(map[lightning.ChannelID]string)
+		current := make(map[lightning.ChannelID]lightning.FeePPM)
+		for _, c := range statuses {
+			aliases[c.ChannelID] = c.RemoteNode.Alias
+			current[c.ChannelID] = c.LocalFee
+		}
+
+		run(func() {
+			var changes map[lightning.ChannelID]lightning.FeePPM
+			var err error
+			if apply {
+				changes, err = r.ApplyFees(ctx)
+			} else {
+				changes, err = r.PreviewFees(ctx)
+			}
+			if err != nil {
+				logf(app, log, "unable to update fees: %s", err)
+				return
+			}
+
+			verb := "would set"
+			if apply {
+				verb = "set"
+			}
+			if len(changes) == 0 {
+				logf(app, log, "fees already match liquidity")
+			}
+			for _, id := range sortedIDs(changes) {
+				logf(app, log, "%s %s fee from %.0f to %.0f PPM", verb, aliases[id], float64(current[id]), float64(changes[id]))
+			}
+		})
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		showDetail()
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 's':
+			sortBy = (sortBy + 1) % len(channelSorts)
+		case 'o', 'i':
+			c, ok := selected()
+			if !ok {
+				return nil
+			}
+			if event.Rune() == 'o' {
+				out = c.ChannelID
+			} else {
+				in = c.ChannelID
+			}
+		case 'r':
+			rebalance()
+			return nil
+		case 'p':
+			fees(false)
+			return nil
+		case 'a':
+			fees(true)
+			return nil
+		default:
+			return event
+		}
+
+		draw()
+		return nil
+	})
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u := <-updates:
+				app.QueueUpdateDraw(func() {
+					// sorted in place, so take a copy of the snapshot
+					statuses = append([]raiju.ChannelStatus(nil), u...)
+					draw()
+				})
+			case err := <-errors:
+				logf(app, log, "unable to update channels: %s", err)
+			}
+		}
+	}()
+
 	return container, nil
 }